@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"crypto-aggregator-service/config"
+	"crypto-aggregator-service/internal/fakeexchange"
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Fake Bitso exchange for local development. Point the service at it with
+// vendors.bitso.base_url: http://localhost:8081
+func main() {
+	logger := config.NewLogger()
+	defer config.CloseLogger(logger)
+
+	addr := flag.String("addr", ":8081", "listen address")
+	books := flag.String("books", "", "served books and starting prices, e.g. btc_mxn=1100000,eth_mxn=58000 (default: a built-in set)")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "random walk seed")
+	volatility := flag.Float64("volatility", 0.001, "random walk step as a fraction of the price, 0 for fixed prices")
+	delay := flag.Duration("delay", 0, "delay every reply, e.g. 2s")
+	rateLimit := flag.Float64("rate-limit", 0, "share of requests answered with 429")
+	errorRate := flag.Float64("error-rate", 0, "share of requests answered with 500")
+	flag.Parse()
+
+	cfg := fakeexchange.Config{
+		Seed:          *seed,
+		Volatility:    *volatility,
+		Delay:         *delay,
+		RateLimitRate: *rateLimit,
+		ErrorRate:     *errorRate,
+	}
+	if *books != "" {
+		parsed, err := fakeexchange.ParseBooks(*books)
+		if err != nil {
+			logger.Fatalf("Invalid books. %v", err)
+		}
+		cfg.Books = parsed
+	}
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           fakeexchange.New(cfg).Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		logger.Infof("Fake exchange listening on %s", *addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatalf("Fake exchange failed. %v", err)
+		}
+	}()
+
+	done := make(chan os.Signal, 1)
+	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	<-done
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = server.Shutdown(ctx)
+	logger.Info("Fake exchange stopped")
+}
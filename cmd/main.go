@@ -3,11 +3,11 @@ package main
 import (
 	"context"
 	"crypto-aggregator-service/config"
-	"crypto-aggregator-service/internal/adapters"
 	httpAPI "crypto-aggregator-service/internal/adapters/httpapi"
 	"crypto-aggregator-service/internal/adapters/webclients"
 	"crypto-aggregator-service/internal/repositories"
 	"crypto-aggregator-service/internal/services"
+	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"go.uber.org/zap"
+	"golang.org/x/time/rate"
 )
 
 func main() {
@@ -25,7 +26,7 @@ func main() {
 	// Configs
 	configs := config.LoadConfig(logger)
 
-	logger.Infof("Application started, configs: %v", configs)
+	logger.Infof("Application started, configs: %+v", configs.Redacted())
 
 	//Layout
 
@@ -40,24 +41,151 @@ func main() {
 
 	//cleanLayout := configs.App.ToDomain()
 	layoutStore := repositories.NewLayoutStore(layout)
-	vendorsMap := configs.App.GetVendorMap()
-	logger.Infof("Loaded vendors: %v", vendorsMap)
+	sourcePolicies, err := configs.App.GetSourcePolicies()
+	if err != nil {
+		logger.Fatalf("Invalid layout configuration. %v", err)
+	}
+	logger.Infof("Loaded vendors: %v", sourcePolicies)
 
 	// Providers
 
-	httpClient := webclients.NewClient(3 * time.Second)
+	registry := repositories.NewDefaultRegistry(func(vendor string, cfg repositories.VendorConfig) *http.Client {
+		var opts []webclients.Option
+		if cfg.RateLimit.RPS > 0 {
+			limiter := rate.NewLimiter(rate.Limit(cfg.RateLimit.RPS), max(cfg.RateLimit.Burst, 1))
+			opts = append(opts, webclients.WithRateLimit(vendor, limiter))
+		}
+		if cfg.Retry.MaxAttempts > 1 {
+			opts = append(opts, webclients.WithRetry(vendor, webclients.RetryPolicy{
+				MaxAttempts: cfg.Retry.MaxAttempts,
+				BaseDelay:   cfg.Retry.BaseDelay,
+				MaxDelay:    cfg.Retry.MaxDelay,
+			}))
+		}
+		return webclients.NewClient(cfg.Timeout, opts...)
+	})
+	vendorConfigs := configs.GetVendorConfigs()
+	clients, err := registry.BuildAll(vendorConfigs)
+	if err != nil {
+		logger.Fatalf("Invalid vendors configuration. %v", err)
+	}
+
+	// Assets, refreshed once before checking the layout so the vendor can list tickers missing from the file
+	assets := repositories.NewAssetRegistry(nil)
+	if configs.Assets.File != "" {
+		assets, err = repositories.LoadAssetRegistry(configs.Assets.File)
+		if err != nil {
+			logger.Fatalf("Failed to load assets. %v", err)
+		}
+	}
+	var assetRefresher *services.AssetRefresher
+	if configs.Assets.Vendor != "" {
+		source, ok := clients[configs.Assets.Vendor].(repositories.AssetSource)
+		if !ok {
+			logger.Fatalf("Vendor %q cannot list assets", configs.Assets.Vendor)
+		}
+		assetRefresher = services.NewAssetRefresher(assets, source, logger)
+		refreshCtx, cancelRefresh := context.WithTimeout(context.Background(), 10*time.Second)
+		assetRefresher.Refresh(refreshCtx)
+		cancelRefresh()
+	}
+	if err := assets.ValidateLayout(layout); err != nil {
+		logger.Fatalf("Invalid layout. %v", err)
+	}
+
+	// FX
+	var pollerOpts []services.PollerOption
+	switch configs.FX.Provider {
+	case "bitso":
+		// Share the ticker cache with the bitso vendor when it is configured
+		bitso, ok := clients["bitso"].(*repositories.BitsoProvider)
+		if !ok {
+			bitso = repositories.NewBitsoCryptoProvider(webclients.NewClient(0))
+		}
+		fx := repositories.NewBitsoFXProvider(bitso)
+		if configs.FX.RefreshInterval > 0 {
+			fx.TTL = time.Duration(configs.FX.RefreshInterval) * time.Second
+		}
+		pollerOpts = append(pollerOpts, services.WithFX(fx))
+	case "static":
+		fx, err := repositories.LoadStaticFXProvider(configs.FX.File)
+		if err != nil {
+			logger.Fatalf("Failed to load fx rates. %v", err)
+		}
+		pollerOpts = append(pollerOpts, services.WithFX(fx))
+	case "":
+		logger.Warn("FX conversion disabled, missing currencies will be left empty")
+	default:
+		logger.Fatalf("Unknown fx provider %q", configs.FX.Provider)
+	}
+
+	scales, err := configs.App.GetScales()
+	if err != nil {
+		logger.Fatalf("Invalid app configuration. %v", err)
+	}
+	pollerOpts = append(pollerOpts,
+		services.WithScales(scales),
+		services.WithAssets(assets),
+		services.WithStaleAfter(time.Duration(configs.App.StaleAfter)*time.Second))
+
+	if configs.App.MockFallback {
+		logger.Warn("Mock fallback enabled, unregistered vendors will serve random prices")
+		pollerOpts = append(pollerOpts, services.WithMockFallback())
+	}
 
-	clients := map[string]repositories.CryptoClient{
-		"bitso": repositories.NewBitsoCryptoProvider(httpClient),
-		"mock":  &adapters.MockClient{},
+	// Circuit breakers, wrapped after the FX lookup above and the ticker TTL
+	// below need the concrete bitso client
+	bitso, _ := clients["bitso"].(*repositories.BitsoProvider)
+	breakers := make(map[string]*repositories.CircuitBreaker)
+	for name, cfg := range vendorConfigs {
+		if cfg.Breaker.FailureThreshold > 0 {
+			breakers[name] = repositories.NewCircuitBreaker(name, cfg.Breaker)
+			clients[name] = repositories.WithCircuitBreaker(clients[name], breakers[name])
+		}
 	}
 
 	// Poller
-	poller := services.NewPoller(layoutStore, clients, vendorsMap, logger)
+	poller := services.NewPoller(layoutStore, clients, sourcePolicies, logger, pollerOpts...)
+	if err := poller.Validate(); err != nil {
+		if configs.App.StrictVendors {
+			logger.Fatalf("Invalid vendor configuration. %v", err)
+		}
+		logger.Warnf("Invalid vendor configuration. %v", err)
+	}
+
+	// A bitso snapshot that outlives the shortest interval would answer every
+	// other cycle of that interval with the previous cycle's prices
+	refreshInterval := time.Duration(configs.Server.RefreshInterval) * time.Second
+	if every := poller.MinInterval(refreshInterval); bitso != nil && bitso.TickerTTL >= every {
+		bitso.TickerTTL = every / 2
+	}
 	ctx, cancel := context.WithCancel(context.Background())
 
 	// Start polling loop in a goroutine
-	go poller.Start(ctx, time.Duration(configs.Server.RefreshInterval))
+	go poller.Start(ctx, refreshInterval)
+
+	if assetRefresher != nil && configs.Assets.RefreshInterval > 0 {
+		go assetRefresher.Start(ctx, time.Duration(configs.Assets.RefreshInterval)*time.Second)
+	}
+
+	// Streaming
+	if configs.Stream.Enabled {
+		stream := repositories.NewBitsoStream(configs.Stream.URL)
+		if configs.Stream.ReadTimeout > 0 {
+			stream.ReadTimeout = time.Duration(configs.Stream.ReadTimeout) * time.Second
+		}
+		streamer := services.NewStreamer(stream, poller, logger)
+		// Subscribe only to the books the ticker snapshot lists
+		if bitso != nil {
+			streamer.Books = bitso
+		} else {
+			streamer.Books = repositories.NewBitsoCryptoProvider(webclients.NewClient(10 * time.Second))
+		}
+		if configs.Stream.MaxBackoff > 0 {
+			streamer.MaxBackoff = time.Duration(configs.Stream.MaxBackoff) * time.Second
+		}
+		go streamer.Start(ctx)
+	}
 
 	// HttpServer
 	httpServer := httpAPI.NewHTTPServer(logger, configs.Server)
@@ -66,6 +194,8 @@ func main() {
 
 	httpAPI.NewPollerController(httpServer, poller)
 
+	httpAPI.NewVendorStatusController(httpServer, breakers)
+
 	//httpServer.Start()
 
 	// Graceful Shutdown Channel
@@ -2,7 +2,10 @@ package config
 
 import (
 	"crypto-aggregator-service/internal/models"
+	"crypto-aggregator-service/internal/repositories"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/knadh/koanf"
 	"github.com/knadh/koanf/parsers/yaml"
@@ -16,6 +19,43 @@ type Configurations struct {
 	Server ServerConfigurations `koanf:"server"`
 	App    AppConfigurations    `koanf:"app"`
 	Keys   KeysConfigurations   `koanf:"keys"`
+	FX     FXConfigurations     `koanf:"fx"`
+	Assets AssetsConfigurations `koanf:"assets"`
+	Stream StreamConfigurations `koanf:"stream"`
+	// Vendors decides which vendors are instantiated, keyed by the name used in the layout
+	Vendors map[string]VendorConfigurations `koanf:"vendors"`
+}
+
+// redacted replaces a configured secret so logs show it is set without its value
+const redacted = "****"
+
+// Redacted returns a copy safe to log: API keys, vendor credentials and REST
+// header values, which may carry tokens, are masked
+func (c Configurations) Redacted() Configurations {
+	mask := func(secret string) string {
+		if secret == "" {
+			return ""
+		}
+		return redacted
+	}
+
+	c.Keys.Public = mask(c.Keys.Public)
+	c.Keys.CoinMarketCap = mask(c.Keys.CoinMarketCap)
+	vendors := make(map[string]VendorConfigurations, len(c.Vendors))
+	for name, v := range c.Vendors {
+		v.Credentials.Key = mask(v.Credentials.Key)
+		v.Credentials.Secret = mask(v.Credentials.Secret)
+		if len(v.Headers) > 0 {
+			headers := make(map[string]string, len(v.Headers))
+			for header, value := range v.Headers {
+				headers[header] = mask(value)
+			}
+			v.Headers = headers
+		}
+		vendors[name] = v
+	}
+	c.Vendors = vendors
+	return c
 }
 
 // ServerConfigurations Server configurations
@@ -27,11 +67,181 @@ type ServerConfigurations struct {
 // AppConfigurations App configurations
 type AppConfigurations struct {
 	Layout []ItemConfig `koanf:"layout"`
+	// StrictVendors refuses to start when a layout vendor is not registered.
+	StrictVendors bool `koanf:"strict_vendors"`
+	// MockFallback serves unregistered vendors with random mock prices.
+	MockFallback bool `koanf:"mock_fallback"`
+	// Currencies are the quote currencies of every component without its own
+	// list (USD and MXN when empty).
+	Currencies []string `koanf:"currencies"`
+	// Scales are the decimal places kept per asset symbol (8 when missing).
+	Scales map[string]int `koanf:"scales"`
+	// StaleAfter flags prices older than this many seconds as stale (0 never does).
+	StaleAfter int `koanf:"stale_after"`
 }
 
-// KeysConfigurations asymmetric keys
+// KeysConfigurations asymmetric keys and vendor API keys
 type KeysConfigurations struct {
-	Public string `koanf:"public"`
+	Public        string `koanf:"public"`
+	CoinMarketCap string `koanf:"coinmarketcap"` // used when vendors.coinmarketcap has no credentials
+}
+
+// FXConfigurations Fiat conversion source used to fill currencies a vendor
+// does not quote natively
+type FXConfigurations struct {
+	Provider        string `koanf:"provider"` // bitso | static | "" (disabled)
+	File            string `koanf:"file"`     // rates file for the static provider
+	RefreshInterval int    `koanf:"refresh_interval"`
+}
+
+// AssetsConfigurations Registry of names, decimals and icons. Every layout
+// ticker must be listed
+type AssetsConfigurations struct {
+	File            string `koanf:"file"`
+	Vendor          string `koanf:"vendor"`           // vendor that lists new assets, "" disables refreshing
+	RefreshInterval int    `koanf:"refresh_interval"` // seconds
+}
+
+// StreamConfigurations Bitso WebSocket ingestion. While connected it replaces
+// polling for components whose primary vendor is bitso
+type StreamConfigurations struct {
+	Enabled     bool   `koanf:"enabled"`
+	URL         string `koanf:"url"`
+	ReadTimeout int    `koanf:"read_timeout"` // seconds without messages before reconnecting
+	MaxBackoff  int    `koanf:"max_backoff"`  // seconds
+}
+
+// VendorConfigurations one vendor instance. Type defaults to the vendor name;
+// type rest reads the RESTVendorConfigurations fields
+type VendorConfigurations struct {
+	Type      string `koanf:"type"`
+	BaseURL   string `koanf:"base_url"`
+	Timeout   int    `koanf:"timeout"` // seconds
+	RateLimit struct {
+		RPS   float64 `koanf:"rps"`
+		Burst int     `koanf:"burst"`
+	} `koanf:"rate_limit"`
+	Retry struct {
+		MaxAttempts int `koanf:"max_attempts"` // 0 or 1 disables retries
+		BaseDelayMS int `koanf:"base_delay_ms"`
+		MaxDelayMS  int `koanf:"max_delay_ms"`
+	} `koanf:"retry"`
+	CircuitBreaker struct {
+		FailureThreshold int `koanf:"failure_threshold"` // 0 disables the breaker
+		OpenTimeout      int `koanf:"open_timeout"`      // seconds
+		HalfOpenProbes   int `koanf:"half_open_probes"`
+	} `koanf:"circuit_breaker"`
+	Credentials struct {
+		Key    string `koanf:"key"`
+		Secret string `koanf:"secret"`
+	} `koanf:"credentials"`
+	RESTVendorConfigurations `koanf:",squash"`
+	MockVendorConfigurations `koanf:",squash"`
+}
+
+// MockVendorConfigurations settings of the mock vendor. Any value set switches
+// it from plain random prices to a seeded random walk
+type MockVendorConfigurations struct {
+	Seed       int64              `koanf:"seed"`
+	Prices     map[string]float64 `koanf:"prices"` // USD starting price per symbol
+	Volatility float64            `koanf:"volatility"`
+	LatencyMS  int                `koanf:"latency_ms"`
+	ErrorRate  float64            `koanf:"error_rate"`
+	Replay     string             `koanf:"replay"` // CSV of symbol,usd,mxn served in order
+}
+
+// ToMock maps the mock settings to the registry configuration
+func (c MockVendorConfigurations) ToMock() repositories.MockConfig {
+	return repositories.MockConfig{
+		Seed:       c.Seed,
+		Prices:     c.Prices,
+		Volatility: c.Volatility,
+		Latency:    time.Duration(c.LatencyMS) * time.Millisecond,
+		ErrorRate:  c.ErrorRate,
+		Replay:     c.Replay,
+	}
+}
+
+// RESTVendorConfigurations a vendor served by the generic REST client.
+// url, headers and the paths accept {symbol}, {quote} and {base_url}
+type RESTVendorConfigurations struct {
+	URL        string            `koanf:"url"`
+	Headers    map[string]string `koanf:"headers"`
+	Quotes     []string          `koanf:"quotes"`  // also read by built-in vendors; REST defaults to USD
+	Aliases    map[string]string `koanf:"aliases"` // canonical ticker -> vendor name
+	Case       string            `koanf:"case"`    // upper | lower
+	PricePath  string            `koanf:"price_path"`
+	VolumePath string            `koanf:"volume_path"`
+	Number     struct {
+		DecimalSeparator   string  `koanf:"decimal_separator"`
+		ThousandsSeparator string  `koanf:"thousands_separator"`
+		Scale              float64 `koanf:"scale"`
+	} `koanf:"number"`
+	Success struct {
+		Path   string `koanf:"path"`
+		Equals string `koanf:"equals"`
+	} `koanf:"success"`
+	ErrorPath string `koanf:"error_path"`
+}
+
+// ToSpec Helper to convert Config -> RESTSpec
+func (c RESTVendorConfigurations) ToSpec(name string) repositories.RESTSpec {
+	spec := repositories.RESTSpec{
+		Name:       name,
+		URL:        c.URL,
+		Headers:    c.Headers,
+		Currencies: c.Quotes,
+		Aliases:    c.Aliases,
+		Case:       c.Case,
+		PricePath:  c.PricePath,
+		VolumePath: c.VolumePath,
+		Number: repositories.NumberFormat{
+			DecimalSeparator:   c.Number.DecimalSeparator,
+			ThousandsSeparator: c.Number.ThousandsSeparator,
+			Scale:              c.Number.Scale,
+		},
+		ErrorPath: c.ErrorPath,
+	}
+	if c.Success.Path != "" {
+		spec.Success = &repositories.RESTPredicate{Path: c.Success.Path, Equals: c.Success.Equals}
+	}
+	return spec
+}
+
+// GetVendorConfigs Helper to convert the vendors section (Name -> VendorConfig)
+func (c *Configurations) GetVendorConfigs() map[string]repositories.VendorConfig {
+	m := make(map[string]repositories.VendorConfig, len(c.Vendors))
+	for name, v := range c.Vendors {
+		cfg := repositories.VendorConfig{
+			Type:       v.Type,
+			BaseURL:    v.BaseURL,
+			Timeout:    time.Duration(v.Timeout) * time.Second,
+			Key:        v.Credentials.Key,
+			Secret:     v.Credentials.Secret,
+			Currencies: v.Quotes,
+			RateLimit: repositories.RateLimit{
+				RPS:   v.RateLimit.RPS,
+				Burst: v.RateLimit.Burst,
+			},
+			Retry: repositories.RetryConfig{
+				MaxAttempts: v.Retry.MaxAttempts,
+				BaseDelay:   time.Duration(v.Retry.BaseDelayMS) * time.Millisecond,
+				MaxDelay:    time.Duration(v.Retry.MaxDelayMS) * time.Millisecond,
+			},
+			Breaker: repositories.BreakerConfig{
+				FailureThreshold: v.CircuitBreaker.FailureThreshold,
+				OpenTimeout:      time.Duration(v.CircuitBreaker.OpenTimeout) * time.Second,
+				HalfOpenProbes:   v.CircuitBreaker.HalfOpenProbes,
+			},
+			REST: v.ToSpec(name),
+			Mock: v.ToMock(),
+		}
+		if name == "coinmarketcap" && cfg.Key == "" {
+			cfg.Key = c.Keys.CoinMarketCap
+		}
+		m[name] = cfg
+	}
+	return m
 }
 
 // ItemConfig represents a row in config.json.
@@ -40,6 +250,37 @@ type ItemConfig struct {
 	ID        int    `json:"id"`
 	Component string `json:"component"`
 	Vendor    string `json:"vendor"` // Configuration only!
+	// Vendors lists several vendors for the component; it takes precedence over Vendor.
+	// Without Aggregation it is an ordered fallback chain.
+	Vendors     []string `json:"vendors" koanf:"vendors"`
+	Aggregation string   `json:"aggregation" koanf:"aggregation"` // median | trimmed_mean | vwap
+	// Currencies overrides the layout quote currencies for this component.
+	Currencies []string `json:"currencies" koanf:"currencies"`
+	// HedgeDelayMS queries the next vendor of the chain in parallel when the
+	// current one is slower than this. Only valid without Aggregation.
+	HedgeDelayMS int `json:"hedge_delay_ms" koanf:"hedge_delay_ms"`
+	// StaleAfter overrides app.stale_after for this component (seconds).
+	StaleAfter int `json:"stale_after" koanf:"stale_after"`
+	// RefreshInterval overrides server.refresh_interval for this component (seconds).
+	RefreshInterval int `json:"refresh_interval" koanf:"refresh_interval"`
+}
+
+// normalizeCurrencies upper-cases and validates a list of ISO codes, dropping
+// duplicates.
+func normalizeCurrencies(currencies []string) ([]string, error) {
+	var out []string
+	seen := make(map[string]bool, len(currencies))
+	for _, c := range currencies {
+		c = strings.ToUpper(strings.TrimSpace(c))
+		if !models.IsCurrencyCode(c) {
+			return nil, fmt.Errorf("invalid currency %q", c)
+		}
+		if !seen[c] {
+			seen[c] = true
+			out = append(out, c)
+		}
+	}
+	return out, nil
 }
 
 // LoadConfig Loads configurations depending upon the environment
@@ -82,11 +323,68 @@ func (c *AppConfigurations) ToModel() []models.Component {
 	return list
 }
 
-// GetVendorMap Helper to extract Vendor Map (ID -> Vendor)
+// GetVendorMap Helper to extract Vendor Map (ID -> Primary Vendor)
 func (c *AppConfigurations) GetVendorMap() map[int]string {
 	m := make(map[int]string)
 	for _, item := range c.Layout {
 		m[item.ID] = item.Vendor
+		if len(item.Vendors) > 0 {
+			m[item.ID] = item.Vendors[0]
+		}
 	}
 	return m
 }
+
+// GetSourcePolicies Helper to extract the vendors and aggregation of every component (ID -> Policy)
+func (c *AppConfigurations) GetSourcePolicies() (map[int]models.SourcePolicy, error) {
+	m := make(map[int]models.SourcePolicy)
+	for _, item := range c.Layout {
+		vendors := item.Vendors
+		if len(vendors) == 0 && item.Vendor != "" {
+			vendors = []string{item.Vendor}
+		}
+
+		aggregation, err := models.ParseAggregation(item.Aggregation)
+		if err != nil {
+			return nil, fmt.Errorf("component %d: %w", item.ID, err)
+		}
+
+		currencies := item.Currencies
+		if len(currencies) == 0 {
+			currencies = c.Currencies
+		}
+		currencies, err = normalizeCurrencies(currencies)
+		if err != nil {
+			return nil, fmt.Errorf("component %d: %w", item.ID, err)
+		}
+
+		if item.HedgeDelayMS > 0 && aggregation != models.AggregationNone {
+			return nil, fmt.Errorf("component %d: hedge_delay_ms needs a fallback chain, not an aggregation", item.ID)
+		}
+		if item.RefreshInterval < 0 {
+			return nil, fmt.Errorf("component %d: refresh_interval must not be negative", item.ID)
+		}
+
+		m[item.ID] = models.SourcePolicy{
+			Vendors:         vendors,
+			Aggregation:     aggregation,
+			Currencies:      currencies,
+			HedgeDelay:      time.Duration(item.HedgeDelayMS) * time.Millisecond,
+			StaleAfter:      time.Duration(item.StaleAfter) * time.Second,
+			RefreshInterval: time.Duration(item.RefreshInterval) * time.Second,
+		}
+	}
+	return m, nil
+}
+
+// GetScales Helper to extract the decimal places kept per asset (Symbol -> Scale)
+func (c *AppConfigurations) GetScales() (map[string]int32, error) {
+	m := make(map[string]int32, len(c.Scales))
+	for symbol, scale := range c.Scales {
+		if scale < 0 || scale > 18 {
+			return nil, fmt.Errorf("scale of %s must be between 0 and 18, got %d", strings.ToUpper(symbol), scale)
+		}
+		m[strings.ToUpper(symbol)] = int32(scale)
+	}
+	return m, nil
+}
@@ -3,7 +3,10 @@ package config
 import (
 	"context"
 	"crypto-aggregator-service/internal/models"
+	"crypto-aggregator-service/internal/repositories"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -78,11 +81,256 @@ func TestConfigurations_Structures(t *testing.T) {
 		App: AppConfigurations{
 			Layout: []ItemConfig{{ID: 1, Component: "test", Vendor: "mock"}},
 		},
-		Keys: KeysConfigurations{Public: "test-key"},
+		Keys: KeysConfigurations{Public: "test-key", CoinMarketCap: "cmc-key"},
 	}
 
 	assert.Equal(t, 3000, cfg.Server.Port)
 	assert.Equal(t, 10, cfg.Server.RefreshInterval)
 	assert.Equal(t, "test-key", cfg.Keys.Public)
+	assert.Equal(t, "cmc-key", cfg.Keys.CoinMarketCap)
 	assert.Len(t, cfg.App.Layout, 1)
 }
+
+func TestAppConfigurations_GetSourcePolicies(t *testing.T) {
+	app := AppConfigurations{
+		Layout: []ItemConfig{
+			{ID: 1, Component: "crypto_btc", Vendor: "bitso"},
+			{ID: 2, Component: "crypto_eth", Vendors: []string{"bitso", "coinbase", "kraken"}, Aggregation: "median"},
+			{ID: 3, Component: "crypto_xrp", Vendor: "mock", Vendors: []string{"binance"}},
+		},
+	}
+
+	result, err := app.GetSourcePolicies()
+
+	require.NoError(t, err)
+	require.Len(t, result, 3)
+	assert.Equal(t, []string{"bitso"}, result[1].Vendors)
+	assert.Equal(t, models.AggregationNone, result[1].Aggregation)
+	assert.Equal(t, []string{"bitso", "coinbase", "kraken"}, result[2].Vendors)
+	assert.Equal(t, models.AggregationMedian, result[2].Aggregation)
+	assert.Equal(t, []string{"binance"}, result[3].Vendors)
+}
+
+func TestAppConfigurations_GetSourcePolicies_InvalidAggregation(t *testing.T) {
+	app := AppConfigurations{
+		Layout: []ItemConfig{{ID: 7, Component: "crypto_btc", Vendors: []string{"bitso"}, Aggregation: "mode"}},
+	}
+
+	_, err := app.GetSourcePolicies()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "component 7")
+}
+
+func TestAppConfigurations_GetSourcePolicies_HedgeDelay(t *testing.T) {
+	app := AppConfigurations{
+		Layout: []ItemConfig{{ID: 1, Component: "crypto_btc", Vendors: []string{"bitso", "coinbase"}, HedgeDelayMS: 250}},
+	}
+
+	result, err := app.GetSourcePolicies()
+
+	require.NoError(t, err)
+	assert.Equal(t, 250*time.Millisecond, result[1].HedgeDelay)
+	assert.True(t, result[1].Hedged())
+}
+
+func TestAppConfigurations_GetSourcePolicies_StaleAfter(t *testing.T) {
+	app := AppConfigurations{
+		Layout: []ItemConfig{{ID: 1, Component: "crypto_btc", Vendor: "bitso", StaleAfter: 15}},
+	}
+
+	result, err := app.GetSourcePolicies()
+
+	require.NoError(t, err)
+	assert.Equal(t, 15*time.Second, result[1].StaleAfter)
+}
+
+func TestAppConfigurations_GetSourcePolicies_RefreshInterval(t *testing.T) {
+	app := AppConfigurations{
+		Layout: []ItemConfig{
+			{ID: 1, Component: "crypto_btc", Vendor: "bitso", RefreshInterval: 2},
+			{ID: 2, Component: "crypto_xrp", Vendor: "bitso"},
+		},
+	}
+
+	result, err := app.GetSourcePolicies()
+
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Second, result[1].RefreshInterval)
+	assert.Zero(t, result[2].RefreshInterval, "uses server.refresh_interval")
+}
+
+func TestAppConfigurations_GetSourcePolicies_NegativeRefreshInterval(t *testing.T) {
+	app := AppConfigurations{
+		Layout: []ItemConfig{{ID: 4, Component: "crypto_btc", Vendor: "bitso", RefreshInterval: -1}},
+	}
+
+	_, err := app.GetSourcePolicies()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "component 4")
+}
+
+func TestAppConfigurations_GetSourcePolicies_HedgeNeedsFallbackChain(t *testing.T) {
+	app := AppConfigurations{
+		Layout: []ItemConfig{{ID: 4, Component: "crypto_btc", Vendors: []string{"bitso", "coinbase"}, Aggregation: "median", HedgeDelayMS: 250}},
+	}
+
+	_, err := app.GetSourcePolicies()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "component 4")
+}
+
+func TestAppConfigurations_GetSourcePolicies_Currencies(t *testing.T) {
+	app := AppConfigurations{
+		Currencies: []string{"usd", "brl"},
+		Layout: []ItemConfig{
+			{ID: 1, Component: "crypto_btc", Vendor: "bitso"},
+			{ID: 2, Component: "crypto_eth", Vendor: "bitso", Currencies: []string{"EUR", "ars", "EUR"}},
+		},
+	}
+
+	result, err := app.GetSourcePolicies()
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"USD", "BRL"}, result[1].Currencies)
+	assert.Equal(t, []string{"EUR", "ARS"}, result[2].Currencies)
+}
+
+func TestAppConfigurations_GetSourcePolicies_DefaultCurrencies(t *testing.T) {
+	app := AppConfigurations{Layout: []ItemConfig{{ID: 1, Component: "crypto_btc", Vendor: "bitso"}}}
+
+	result, err := app.GetSourcePolicies()
+
+	require.NoError(t, err)
+	assert.Empty(t, result[1].Currencies)
+	assert.Equal(t, models.DefaultCurrencies, result[1].QuoteCurrencies())
+}
+
+func TestAppConfigurations_GetSourcePolicies_InvalidCurrency(t *testing.T) {
+	app := AppConfigurations{
+		Layout: []ItemConfig{{ID: 9, Component: "crypto_btc", Vendor: "bitso", Currencies: []string{"euro"}}},
+	}
+
+	_, err := app.GetSourcePolicies()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "component 9")
+}
+
+func TestAppConfigurations_GetScales(t *testing.T) {
+	app := AppConfigurations{Scales: map[string]int{"btc": 2, "XRP": 6}}
+
+	scales, err := app.GetScales()
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int32{"BTC": 2, "XRP": 6}, scales)
+
+	app.Scales["ETH"] = 19
+	_, err = app.GetScales()
+	assert.ErrorContains(t, err, "ETH")
+}
+
+func TestAppConfigurations_GetVendorMap_UsesFirstOfVendors(t *testing.T) {
+	app := AppConfigurations{
+		Layout: []ItemConfig{{ID: 1, Component: "crypto_btc", Vendors: []string{"kraken", "bitso"}}},
+	}
+
+	assert.Equal(t, "kraken", app.GetVendorMap()[1])
+}
+
+func TestRESTVendorConfigurations_ToSpec(t *testing.T) {
+	c := RESTVendorConfigurations{
+		URL:       "{base_url}/price/{symbol}-{quote}",
+		Quotes:    []string{"USD", "MXN"},
+		PricePath: "data.price",
+	}
+	c.Number.DecimalSeparator = ","
+	c.Success.Path = "ok"
+
+	spec := c.ToSpec("example")
+
+	assert.Equal(t, "example", spec.Name)
+	assert.Equal(t, []string{"USD", "MXN"}, spec.Currencies)
+	assert.Equal(t, ",", spec.Number.DecimalSeparator)
+	require.NotNil(t, spec.Success)
+	assert.Equal(t, "ok", spec.Success.Path)
+
+	c.Success.Path = ""
+	assert.Nil(t, c.ToSpec("example").Success)
+}
+
+func TestConfigurations_GetVendorConfigs(t *testing.T) {
+	cmc := VendorConfigurations{Timeout: 5}
+	cmc.RateLimit.RPS, cmc.RateLimit.Burst = 0.5, 2
+	cmc.Retry.MaxAttempts, cmc.Retry.BaseDelayMS, cmc.Retry.MaxDelayMS = 3, 100, 800
+	cmc.CircuitBreaker.FailureThreshold, cmc.CircuitBreaker.OpenTimeout = 3, 20
+
+	internal := VendorConfigurations{Type: "rest", BaseURL: "https://prices.internal"}
+	internal.URL = "{base_url}/{symbol}"
+	internal.Credentials.Key = "internal-key"
+
+	cfg := Configurations{
+		Keys: KeysConfigurations{CoinMarketCap: "legacy-key"},
+		Vendors: map[string]VendorConfigurations{
+			"bitso":         {},
+			"coinmarketcap": cmc,
+			"internal":      internal,
+		},
+	}
+
+	result := cfg.GetVendorConfigs()
+
+	require.Len(t, result, 3)
+	assert.Equal(t, "legacy-key", result["coinmarketcap"].Key)
+	assert.Equal(t, 5*time.Second, result["coinmarketcap"].Timeout)
+	assert.Equal(t, repositories.RateLimit{RPS: 0.5, Burst: 2}, result["coinmarketcap"].RateLimit)
+	assert.Zero(t, result["bitso"].RateLimit.RPS)
+	assert.Equal(t, repositories.RetryConfig{MaxAttempts: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: 800 * time.Millisecond}, result["coinmarketcap"].Retry)
+	assert.Equal(t, repositories.BreakerConfig{FailureThreshold: 3, OpenTimeout: 20 * time.Second}, result["coinmarketcap"].Breaker)
+	assert.Zero(t, result["bitso"].Breaker.FailureThreshold)
+	assert.Equal(t, "rest", result["internal"].Type)
+	assert.Equal(t, "https://prices.internal", result["internal"].BaseURL)
+	assert.Equal(t, "internal-key", result["internal"].Key)
+	assert.Equal(t, "{base_url}/{symbol}", result["internal"].REST.URL)
+	assert.Equal(t, "internal", result["internal"].REST.Name)
+}
+
+func TestConfigurations_GetVendorConfigs_Mock(t *testing.T) {
+	mock := VendorConfigurations{}
+	mock.Seed, mock.LatencyMS, mock.ErrorRate = 42, 150, 0.1
+	mock.Prices = map[string]float64{"BTC": 65000}
+	cfg := Configurations{Vendors: map[string]VendorConfigurations{"mock": mock}}
+
+	result := cfg.GetVendorConfigs()
+
+	assert.Equal(t, repositories.MockConfig{
+		Seed:      42,
+		Prices:    map[string]float64{"BTC": 65000},
+		Latency:   150 * time.Millisecond,
+		ErrorRate: 0.1,
+	}, result["mock"].Mock)
+}
+
+func TestConfigurations_Redacted(t *testing.T) {
+	var cfg Configurations
+	cfg.Keys.CoinMarketCap = "cmc-secret"
+	cmc := VendorConfigurations{}
+	cmc.Credentials.Key = "vendor-key"
+	cmc.Credentials.Secret = "vendor-secret"
+	rest := VendorConfigurations{Type: "rest"}
+	rest.Headers = map[string]string{"Authorization": "Bearer token"}
+	cfg.Vendors = map[string]VendorConfigurations{"coinmarketcap": cmc, "internal": rest}
+
+	safe := cfg.Redacted()
+
+	printed := fmt.Sprintf("%+v", safe)
+	for _, secret := range []string{"cmc-secret", "vendor-key", "vendor-secret", "Bearer token"} {
+		assert.NotContains(t, printed, secret)
+	}
+	assert.Equal(t, "****", safe.Vendors["coinmarketcap"].Credentials.Key)
+	assert.Empty(t, safe.Keys.Public, "unset secrets stay empty")
+	assert.Equal(t, "vendor-key", cfg.Vendors["coinmarketcap"].Credentials.Key, "the original is untouched")
+	assert.Equal(t, "Bearer token", cfg.Vendors["internal"].Headers["Authorization"])
+}
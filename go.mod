@@ -5,12 +5,14 @@ go 1.25
 require (
 	github.com/go-chi/chi/v5 v5.2.5
 	github.com/goccy/go-json v0.10.5
+	github.com/gorilla/websocket v1.5.3
 	github.com/knadh/koanf v1.5.0
 	github.com/prometheus/client_golang v1.11.1
 	github.com/stretchr/testify v1.11.1
 	go.elastic.co/apm/module/apmchiv5/v2 v2.7.3
 	go.elastic.co/ecszap v1.0.3
 	go.uber.org/zap v1.27.1
+	golang.org/x/time v0.9.0
 )
 
 require (
@@ -27,5 +27,5 @@ func NewPollerController(server *HTTPServer, poller *services.Poller) *PollerCon
 
 func (pc *PollerController) handleFetch(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	RenderJSON(ctx, w, http.StatusOK, pc.poller.Store.GetLayout())
+	RenderJSON(ctx, w, http.StatusOK, pc.poller.Layout())
 }
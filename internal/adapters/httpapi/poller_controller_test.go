@@ -5,6 +5,7 @@ import (
 	"crypto-aggregator-service/internal/models"
 	"crypto-aggregator-service/internal/repositories"
 	"crypto-aggregator-service/internal/services"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -62,3 +63,31 @@ func TestPollerController_Fetch_WithData(t *testing.T) {
 	require.NoError(t, err)
 	assert.Len(t, result, 2)
 }
+
+func TestPollerController_Fetch_RendersStatus(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	server := NewHTTPServer(logger, config.ServerConfigurations{Port: 3000})
+
+	store := repositories.NewLayoutStore([]models.Component{
+		{ID: 1, Component: "crypto_btc"},
+		{ID: 2, Component: "crypto_eth"},
+	})
+	store.UpdateStatus(0, func(s models.ComponentStatus) models.ComponentStatus {
+		return s.Failed(errors.New("bitso: provider unavailable"))
+	})
+	NewPollerController(server, services.NewPoller(store, nil, nil, logger))
+
+	req := httptest.NewRequest(http.MethodGet, "/fetch", nil)
+	w := httptest.NewRecorder()
+	server.Router.ServeHTTP(w, req)
+
+	var result []map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, map[string]any{
+		"state":                "error",
+		"last_error":           "bitso: provider unavailable",
+		"consecutive_failures": float64(1),
+		"last_success":         nil,
+	}, result[0]["status"])
+	assert.NotContains(t, result[1], "status", "never refreshed")
+}
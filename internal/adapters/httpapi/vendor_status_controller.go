@@ -0,0 +1,38 @@
+package httpapi
+
+import (
+	"crypto-aggregator-service/internal/repositories"
+	"net/http"
+	"sort"
+
+	"go.uber.org/zap"
+)
+
+// VendorStatusController Exposes the circuit breaker state of every vendor
+type VendorStatusController struct {
+	breakers map[string]*repositories.CircuitBreaker
+	logger   *zap.SugaredLogger
+}
+
+// NewVendorStatusController Creates a new instance
+func NewVendorStatusController(server *HTTPServer, breakers map[string]*repositories.CircuitBreaker) *VendorStatusController {
+	vc := &VendorStatusController{
+		breakers: breakers,
+		logger:   server.Logger,
+	}
+
+	// Loads routes
+	server.Router.Get("/status/vendors", vc.handleVendorStatus)
+
+	return vc
+}
+
+func (vc *VendorStatusController) handleVendorStatus(w http.ResponseWriter, r *http.Request) {
+	statuses := make([]repositories.BreakerStatus, 0, len(vc.breakers))
+	for _, breaker := range vc.breakers {
+		statuses = append(statuses, breaker.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Vendor < statuses[j].Vendor })
+
+	RenderJSON(r.Context(), w, http.StatusOK, map[string]any{"vendors": statuses})
+}
@@ -0,0 +1,44 @@
+package httpapi
+
+import (
+	"crypto-aggregator-service/config"
+	"crypto-aggregator-service/internal/models"
+	"crypto-aggregator-service/internal/repositories"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestVendorStatusController_ListsBreakers(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	server := NewHTTPServer(logger, config.ServerConfigurations{Port: 3000})
+
+	down := repositories.NewCircuitBreaker("kraken", repositories.BreakerConfig{FailureThreshold: 1})
+	down.Record(models.VendorError{Vendor: "kraken", Kind: models.ErrProviderUnavailable})
+	NewVendorStatusController(server, map[string]*repositories.CircuitBreaker{
+		"kraken": down,
+		"bitso":  repositories.NewCircuitBreaker("bitso", repositories.BreakerConfig{}),
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/status/vendors", nil)
+	w := httptest.NewRecorder()
+	server.Router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var body struct {
+		Vendors []repositories.BreakerStatus `json:"vendors"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Len(t, body.Vendors, 2)
+	assert.Equal(t, "bitso", body.Vendors[0].Vendor)
+	assert.Equal(t, "closed", body.Vendors[0].State)
+	assert.Equal(t, "kraken", body.Vendors[1].Vendor)
+	assert.Equal(t, "open", body.Vendors[1].State)
+	assert.Equal(t, 1, body.Vendors[1].ConsecutiveFailures)
+}
@@ -3,28 +3,233 @@ package adapters
 import (
 	"context"
 	"crypto-aggregator-service/internal/models"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
 	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"time"
 )
 
-type MockClient struct{}
+// MockClient serves simulated prices. The zero value keeps the original
+// behavior (base price plus up to one unit of noise); NewMockClient builds a
+// deterministic one for reproducible tests and demos.
+type MockClient struct {
+	mu sync.Mutex
+
+	rng        *rand.Rand // nil for the zero value
+	prices     map[string]float64
+	volatility float64
+	mxnRate    float64
+	latency    time.Duration
+	errorRate  float64
+	script     []error
+	replay     map[string][]models.Money
+
+	walk      map[string]float64 // current USD price per symbol
+	replayPos map[string]int
+}
+
+// MockOption configures a MockClient built with NewMockClient.
+type MockOption func(*MockClient)
+
+// WithSeed makes the random walk and the injected failures reproducible.
+func WithSeed(seed int64) MockOption {
+	return func(m *MockClient) { m.rng = rand.New(rand.NewSource(seed)) }
+}
+
+// WithBasePrices sets the USD starting price of each symbol.
+func WithBasePrices(prices map[string]float64) MockOption {
+	return func(m *MockClient) {
+		for symbol, price := range prices {
+			m.prices[strings.ToUpper(symbol)] = price
+		}
+	}
+}
+
+// WithVolatility sets the standard deviation of every random walk step as a
+// fraction of the current price.
+func WithVolatility(volatility float64) MockOption {
+	return func(m *MockClient) { m.volatility = volatility }
+}
+
+// WithMXNRate sets the USD to MXN rate used for the MXN quote.
+func WithMXNRate(rate float64) MockOption {
+	return func(m *MockClient) { m.mxnRate = rate }
+}
+
+// WithLatency delays every answer, honoring context cancellation.
+func WithLatency(latency time.Duration) MockOption {
+	return func(m *MockClient) { m.latency = latency }
+}
+
+// WithErrorRate fails that fraction of calls with models.ErrProviderUnavailable.
+func WithErrorRate(rate float64) MockOption {
+	return func(m *MockClient) { m.errorRate = rate }
+}
+
+// WithScript fails calls in order: the n-th call returns script[n] when it is
+// not nil. Calls past the end of the script behave normally.
+func WithScript(script ...error) MockOption {
+	return func(m *MockClient) { m.script = script }
+}
+
+// WithReplay serves the given prices in order per symbol, looping at the end.
+// Symbols without rows fail with models.ErrUnsupportedSymbol.
+func WithReplay(prices map[string][]models.Money) MockOption {
+	return func(m *MockClient) { m.replay = prices }
+}
+
+// defaultMockPrices are the USD base prices of the zero value MockClient.
+var defaultMockPrices = map[string]float64{
+	"BTC":  10000.0,
+	"ETH":  100.0,
+	"XRP":  0.2,
+	"DOGE": 0.20,
+}
+
+func NewMockClient(opts ...MockOption) *MockClient {
+	m := &MockClient{
+		rng:        rand.New(rand.NewSource(1)),
+		prices:     make(map[string]float64),
+		volatility: 0.001,
+		mxnRate:    20,
+		walk:       make(map[string]float64),
+		replayPos:  make(map[string]int),
+	}
+	for symbol, price := range defaultMockPrices {
+		m.prices[symbol] = price
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
 
 func (m *MockClient) Name() string { return "mock" }
 
 func (m *MockClient) GetPrice(ctx context.Context, symbol string) (*models.Money, error) {
+	if m.rng == nil {
+		return legacyMockPrice(symbol), nil
+	}
+
+	if m.latency > 0 {
+		timer := time.NewTimer(m.latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.script) > 0 {
+		err := m.script[0]
+		m.script = m.script[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	if m.errorRate > 0 && m.rng.Float64() < m.errorRate {
+		return nil, models.VendorError{Vendor: m.Name(), Message: "injected failure", Kind: models.ErrProviderUnavailable}
+	}
+
+	symbol = strings.ToUpper(symbol)
+	if m.replay != nil {
+		return m.next(symbol)
+	}
+
+	price, ok := m.walk[symbol]
+	if !ok {
+		price, ok = m.prices[symbol]
+		if !ok {
+			price = 100.0
+		}
+	} else {
+		price *= 1 + m.volatility*m.rng.NormFloat64()
+	}
+	m.walk[symbol] = price
+
+	return &models.Money{USD: models.DecimalFromFloat(price), MXN: models.DecimalFromFloat(price * m.mxnRate)}, nil
+}
+
+// next returns the following replay row of symbol. Callers hold m.mu.
+func (m *MockClient) next(symbol string) (*models.Money, error) {
+	rows := m.replay[symbol]
+	if len(rows) == 0 {
+		return nil, models.VendorError{Vendor: m.Name(), Message: "no replay prices for " + symbol, Kind: models.ErrUnsupportedSymbol}
+	}
+	pos := m.replayPos[symbol]
+	m.replayPos[symbol] = pos + 1
+
+	price := rows[pos%len(rows)]
+	return &price, nil
+}
+
+func legacyMockPrice(symbol string) *models.Money {
 	// Simulate random fluctuation
-	base := 100.0
-	if symbol == "DOGE" {
-		base = 0.20
-	} else if symbol == "BTC" {
-		base = 10000.0
-	} else if symbol == "ETH" {
+	base, ok := defaultMockPrices[symbol]
+	if !ok {
 		base = 100.0
-	} else if symbol == "XRP" {
-		base = 0.2
 	}
 
 	return &models.Money{
-		USD: base + rand.Float64(),
-		MXN: (base * 20) + rand.Float64(),
-	}, nil
+		USD: models.DecimalFromFloat(base + rand.Float64()),
+		MXN: models.DecimalFromFloat((base * 20) + rand.Float64()),
+	}
+}
+
+// LoadMockReplay reads replay prices from a CSV with the columns
+// symbol,usd,mxn. A header row is optional and an empty mxn is allowed.
+func LoadMockReplay(path string) (map[string][]models.Money, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadMockReplay(f)
+}
+
+// ReadMockReplay is LoadMockReplay over any reader.
+func ReadMockReplay(r io.Reader) (map[string][]models.Money, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	prices := make(map[string][]models.Money)
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && strings.EqualFold(record[0], "symbol") {
+			continue
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("replay line %d: want symbol,usd[,mxn]", line)
+		}
+
+		var price models.Money
+		if price.USD, err = models.ParseDecimal(record[1]); err != nil {
+			return nil, fmt.Errorf("replay line %d: %w", line, err)
+		}
+		if len(record) > 2 && record[2] != "" {
+			if price.MXN, err = models.ParseDecimal(record[2]); err != nil {
+				return nil, fmt.Errorf("replay line %d: %w", line, err)
+			}
+		}
+
+		symbol := strings.ToUpper(record[0])
+		prices[symbol] = append(prices[symbol], price)
+	}
+	return prices, nil
 }
@@ -2,7 +2,10 @@ package adapters
 
 import (
 	"context"
+	"crypto-aggregator-service/internal/models"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -18,10 +21,10 @@ func TestMockClient_GetPrice_BTC(t *testing.T) {
 	price, err := m.GetPrice(context.Background(), "BTC")
 
 	require.NoError(t, err)
-	assert.GreaterOrEqual(t, price.USD, 10000.0)
-	assert.Less(t, price.USD, 10001.0)
-	assert.GreaterOrEqual(t, price.MXN, 200000.0)
-	assert.Less(t, price.MXN, 200001.0)
+	assert.GreaterOrEqual(t, price.USD.Float64(), 10000.0)
+	assert.Less(t, price.USD.Float64(), 10001.0)
+	assert.GreaterOrEqual(t, price.MXN.Float64(), 200000.0)
+	assert.Less(t, price.MXN.Float64(), 200001.0)
 }
 
 func TestMockClient_GetPrice_ETH(t *testing.T) {
@@ -29,8 +32,8 @@ func TestMockClient_GetPrice_ETH(t *testing.T) {
 	price, err := m.GetPrice(context.Background(), "ETH")
 
 	require.NoError(t, err)
-	assert.GreaterOrEqual(t, price.USD, 100.0)
-	assert.Less(t, price.USD, 101.0)
+	assert.GreaterOrEqual(t, price.USD.Float64(), 100.0)
+	assert.Less(t, price.USD.Float64(), 101.0)
 }
 
 func TestMockClient_GetPrice_XRP(t *testing.T) {
@@ -38,8 +41,8 @@ func TestMockClient_GetPrice_XRP(t *testing.T) {
 	price, err := m.GetPrice(context.Background(), "XRP")
 
 	require.NoError(t, err)
-	assert.GreaterOrEqual(t, price.USD, 0.2)
-	assert.Less(t, price.USD, 1.2)
+	assert.GreaterOrEqual(t, price.USD.Float64(), 0.2)
+	assert.Less(t, price.USD.Float64(), 1.2)
 }
 
 func TestMockClient_GetPrice_DOGE(t *testing.T) {
@@ -47,8 +50,8 @@ func TestMockClient_GetPrice_DOGE(t *testing.T) {
 	price, err := m.GetPrice(context.Background(), "DOGE")
 
 	require.NoError(t, err)
-	assert.GreaterOrEqual(t, price.USD, 0.2)
-	assert.Less(t, price.USD, 1.2)
+	assert.GreaterOrEqual(t, price.USD.Float64(), 0.2)
+	assert.Less(t, price.USD.Float64(), 1.2)
 }
 
 func TestMockClient_GetPrice_UnknownSymbol(t *testing.T) {
@@ -57,8 +60,8 @@ func TestMockClient_GetPrice_UnknownSymbol(t *testing.T) {
 
 	require.NoError(t, err)
 	// Default base is 100.0
-	assert.GreaterOrEqual(t, price.USD, 100.0)
-	assert.Less(t, price.USD, 101.0)
+	assert.GreaterOrEqual(t, price.USD.Float64(), 100.0)
+	assert.Less(t, price.USD.Float64(), 101.0)
 }
 
 func TestMockClient_GetPrice_MXNIsBaseTime20(t *testing.T) {
@@ -67,7 +70,7 @@ func TestMockClient_GetPrice_MXNIsBaseTime20(t *testing.T) {
 
 	require.NoError(t, err)
 	// MXN should be approximately base*20 + rand
-	assert.GreaterOrEqual(t, price.MXN, 200000.0)
+	assert.GreaterOrEqual(t, price.MXN.Float64(), 200000.0)
 }
 
 func TestMockClient_ImplementsCryptoClient(t *testing.T) {
@@ -77,3 +80,107 @@ func TestMockClient_ImplementsCryptoClient(t *testing.T) {
 	_, err := m.GetPrice(context.Background(), "BTC")
 	assert.NoError(t, err)
 }
+
+func walk(t *testing.T, m *MockClient, symbol string, n int) []float64 {
+	t.Helper()
+	prices := make([]float64, n)
+	for i := range prices {
+		price, err := m.GetPrice(context.Background(), symbol)
+		require.NoError(t, err)
+		prices[i] = price.USD.Float64()
+	}
+	return prices
+}
+
+func TestNewMockClient_SeededWalkIsReproducible(t *testing.T) {
+	a := walk(t, NewMockClient(WithSeed(42)), "BTC", 20)
+	b := walk(t, NewMockClient(WithSeed(42)), "BTC", 20)
+	c := walk(t, NewMockClient(WithSeed(7)), "BTC", 20)
+
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, c)
+	assert.Equal(t, 10000.0, a[0], "the walk starts at the base price")
+	assert.NotEqual(t, a[0], a[1])
+}
+
+func TestNewMockClient_BasePricesAndMXNRate(t *testing.T) {
+	m := NewMockClient(WithBasePrices(map[string]float64{"btc": 65000}), WithMXNRate(17), WithVolatility(0))
+
+	price, err := m.GetPrice(context.Background(), "BTC")
+	require.NoError(t, err)
+	assert.Equal(t, 65000.0, price.USD.Float64())
+	assert.Equal(t, 65000.0*17, price.MXN.Float64())
+
+	assert.Equal(t, []float64{65000, 65000}, walk(t, m, "BTC", 2), "zero volatility keeps the price flat")
+}
+
+func TestNewMockClient_Script(t *testing.T) {
+	m := NewMockClient(WithScript(models.ErrRateLimited, nil, models.ErrProviderUnavailable))
+
+	_, err := m.GetPrice(context.Background(), "BTC")
+	assert.ErrorIs(t, err, models.ErrRateLimited)
+	_, err = m.GetPrice(context.Background(), "BTC")
+	assert.NoError(t, err)
+	_, err = m.GetPrice(context.Background(), "BTC")
+	assert.ErrorIs(t, err, models.ErrProviderUnavailable)
+	_, err = m.GetPrice(context.Background(), "BTC")
+	assert.NoError(t, err, "calls past the script succeed")
+}
+
+func TestNewMockClient_ErrorRate(t *testing.T) {
+	always := NewMockClient(WithErrorRate(1))
+	_, err := always.GetPrice(context.Background(), "BTC")
+	assert.ErrorIs(t, err, models.ErrProviderUnavailable)
+
+	failures := func(seed int64) int {
+		m := NewMockClient(WithSeed(seed), WithErrorRate(0.5))
+		n := 0
+		for i := 0; i < 100; i++ {
+			if _, err := m.GetPrice(context.Background(), "BTC"); err != nil {
+				n++
+			}
+		}
+		return n
+	}
+	assert.Equal(t, failures(3), failures(3))
+	assert.InDelta(t, 50, failures(3), 20)
+}
+
+func TestNewMockClient_LatencyHonorsContext(t *testing.T) {
+	m := NewMockClient(WithLatency(time.Second))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := m.GetPrice(ctx, "BTC")
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestNewMockClient_ReplayFromCSV(t *testing.T) {
+	replay, err := LoadMockReplay("testdata/mock_prices.csv")
+	require.NoError(t, err)
+	m := NewMockClient(WithReplay(replay))
+
+	assert.Equal(t, []float64{50000, 50100, 50000}, walk(t, m, "btc", 3), "replay loops at the end")
+
+	eth, err := m.GetPrice(context.Background(), "ETH")
+	require.NoError(t, err)
+	assert.Equal(t, models.Money{USD: models.DecimalFromFloat(3000)}, *eth)
+
+	_, err = m.GetPrice(context.Background(), "XRP")
+	assert.ErrorIs(t, err, models.ErrUnsupportedSymbol)
+}
+
+func TestReadMockReplay_Invalid(t *testing.T) {
+	_, err := ReadMockReplay(strings.NewReader("BTC,abc,1\n"))
+	assert.Error(t, err)
+
+	_, err = ReadMockReplay(strings.NewReader("BTC\n"))
+	assert.Error(t, err)
+
+	prices, err := ReadMockReplay(strings.NewReader("BTC,1,17\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []models.Money{{USD: models.DecimalFromFloat(1), MXN: models.DecimalFromFloat(17)}}, prices["BTC"])
+}
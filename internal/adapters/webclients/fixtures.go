@@ -0,0 +1,184 @@
+package webclients
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-json"
+)
+
+// ErrNoFixture is returned by a Replayer for requests that were never recorded.
+var ErrNoFixture = errors.New("no recorded response")
+
+// Fixture is the on-disk list of recorded request/response pairs.
+type Fixture struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded exchange. Request headers are not stored so
+// credentials never end up in fixture files.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+type RecordedResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body"`
+}
+
+// skippedHeaders are volatile or private response headers left out of fixtures.
+var skippedHeaders = map[string]bool{
+	"Date":       true,
+	"Set-Cookie": true,
+}
+
+// LoadFixture reads a fixture file.
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f Fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid fixture %s: %w", path, err)
+	}
+	return &f, nil
+}
+
+// Recorder is an http.RoundTripper that forwards requests to Next and
+// appends every exchange to the fixture file at Path.
+type Recorder struct {
+	Path string
+	Next http.RoundTripper
+
+	mu      sync.Mutex
+	fixture Fixture
+}
+
+func NewRecorder(path string, next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{Path: path, Next: next}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.Next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	recorded := RecordedResponse{Status: resp.StatusCode, Headers: map[string]string{}, Body: string(body)}
+	for name := range resp.Header {
+		if !skippedHeaders[name] {
+			recorded.Headers[name] = resp.Header.Get(name)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fixture.Interactions = append(r.fixture.Interactions, Interaction{
+		Request:  RecordedRequest{Method: req.Method, URL: req.URL.String()},
+		Response: recorded,
+	})
+	if err := r.save(); err != nil {
+		return nil, fmt.Errorf("recording fixture %s: %w", r.Path, err)
+	}
+	return resp, nil
+}
+
+// save rewrites the whole fixture so the file is valid after every request.
+func (r *Recorder) save() error {
+	data, err := json.MarshalIndent(r.fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.Path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.Path, data, 0o644)
+}
+
+// Replayer is an http.RoundTripper serving recorded responses. Requests are
+// matched by method and full URL; repeated requests get the recorded answers
+// in order and then keep getting the last one. Anything else fails with
+// ErrNoFixture.
+type Replayer struct {
+	mu        sync.Mutex
+	responses map[string][]RecordedResponse
+	served    map[string]int
+}
+
+func NewReplayer(fixture *Fixture) *Replayer {
+	r := &Replayer{responses: make(map[string][]RecordedResponse), served: make(map[string]int)}
+	for _, i := range fixture.Interactions {
+		key := replayKey(i.Request.Method, i.Request.URL)
+		r.responses[key] = append(r.responses[key], i.Response)
+	}
+	return r
+}
+
+// LoadReplayer builds a Replayer from a fixture file.
+func LoadReplayer(path string) (*Replayer, error) {
+	f, err := LoadFixture(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplayer(f), nil
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := replayKey(req.Method, req.URL.String())
+
+	r.mu.Lock()
+	responses := r.responses[key]
+	n := r.served[key]
+	r.served[key] = n + 1
+	r.mu.Unlock()
+
+	if len(responses) == 0 {
+		return nil, fmt.Errorf("%w for %s", ErrNoFixture, key)
+	}
+	recorded := responses[min(n, len(responses)-1)]
+
+	header := make(http.Header, len(recorded.Headers))
+	for name, value := range recorded.Headers {
+		header.Set(name, value)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
+
+func replayKey(method, url string) string {
+	return strings.ToUpper(method) + " " + url
+}
@@ -0,0 +1,105 @@
+package webclients
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, client *http.Client, url string) (int, string, http.Header) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Api-Key", "secret")
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(body), resp.Header
+}
+
+func TestRecorderThenReplayer(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path == "/limited" {
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"call":` + strconv.Itoa(calls) + `}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "fixtures", "vendor.json")
+	recording := NewClient(0, WithRecorder(path))
+
+	status, body, _ := get(t, recording, server.URL+"/price?symbol=BTC")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `{"call":1}`, body, "the recorder passes the real response through")
+	get(t, recording, server.URL+"/price?symbol=BTC")
+	get(t, recording, server.URL+"/limited")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret", "request headers are never stored")
+
+	replayer, err := LoadReplayer(path)
+	require.NoError(t, err)
+	replaying := NewClient(0, WithReplayer(replayer))
+	server.Close()
+
+	_, body, header := get(t, replaying, server.URL+"/price?symbol=BTC")
+	assert.Equal(t, `{"call":1}`, body)
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+	_, body, _ = get(t, replaying, server.URL+"/price?symbol=BTC")
+	assert.Equal(t, `{"call":2}`, body)
+	_, body, _ = get(t, replaying, server.URL+"/price?symbol=BTC")
+	assert.Equal(t, `{"call":2}`, body, "the last answer repeats once the recording runs out")
+
+	status, _, header = get(t, replaying, server.URL+"/limited")
+	assert.Equal(t, http.StatusTooManyRequests, status)
+	assert.Equal(t, "3", header.Get("Retry-After"))
+}
+
+func TestReplayer_UnmatchedRequest(t *testing.T) {
+	replayer := NewReplayer(&Fixture{Interactions: []Interaction{{
+		Request:  RecordedRequest{Method: http.MethodGet, URL: "https://api.example.com/price?symbol=BTC"},
+		Response: RecordedResponse{Status: http.StatusOK, Body: `{}`},
+	}}})
+	client := NewClient(0, WithReplayer(replayer))
+
+	_, err := client.Get("https://api.example.com/price?symbol=ETH")
+
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrNoFixture))
+	assert.Contains(t, err.Error(), "GET https://api.example.com/price?symbol=ETH")
+}
+
+func TestLoadFixture_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.json")
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o644))
+
+	_, err := LoadReplayer(path)
+	assert.Error(t, err)
+
+	_, err = LoadReplayer(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestNewClient_DefaultTimeout(t *testing.T) {
+	client := NewClient(0)
+	assert.Positive(t, client.Timeout)
+	assert.IsType(t, &http.Transport{}, client.Transport)
+}
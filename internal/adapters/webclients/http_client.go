@@ -6,7 +6,21 @@ import (
 	"time"
 )
 
-func NewClient(timeout time.Duration) *http.Client {
+// Option customizes the client built by NewClient.
+type Option func(*http.Client)
+
+// WithRecorder stores every request/response pair in the fixture file at path
+// while still talking to the real vendor.
+func WithRecorder(path string) Option {
+	return func(c *http.Client) { c.Transport = NewRecorder(path, c.Transport) }
+}
+
+// WithReplayer serves responses from recorded fixtures instead of the network.
+func WithReplayer(r *Replayer) Option {
+	return func(c *http.Client) { c.Transport = r }
+}
+
+func NewClient(timeout time.Duration, opts ...Option) *http.Client {
 	if timeout <= 0 {
 		timeout = 3 * time.Second
 	}
@@ -23,8 +37,12 @@ func NewClient(timeout time.Duration) *http.Client {
 		ExpectContinueTimeout: 1 * time.Second,
 	}
 
-	return &http.Client{
+	client := &http.Client{
 		Timeout:   timeout,
 		Transport: transport,
 	}
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client
 }
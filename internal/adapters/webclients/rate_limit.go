@@ -0,0 +1,76 @@
+package webclients
+
+import (
+	"crypto-aggregator-service/internal/models"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/time/rate"
+)
+
+var throttledRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "vendor_throttled_requests_total",
+	Help: "Outbound vendor requests held back by the client-side rate limiter.",
+}, []string{"vendor", "outcome"}) // outcome: delayed | rejected
+
+// RateLimitError is returned when a request cannot get a token before its
+// context deadline. It matches models.ErrRateLimited with errors.Is.
+type RateLimitError struct {
+	Vendor string
+	Wait   time.Duration // time until a token would have been available
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s: client-side rate limit, next slot in %s", e.Vendor, e.Wait)
+}
+
+func (e *RateLimitError) Unwrap() error { return models.ErrRateLimited }
+
+// WithRateLimit paces the requests of one vendor with a token bucket.
+func WithRateLimit(vendor string, limiter *rate.Limiter) Option {
+	return func(c *http.Client) {
+		c.Transport = &rateLimitedTransport{vendor: vendor, limiter: limiter, next: c.Transport}
+	}
+}
+
+type rateLimitedTransport struct {
+	vendor  string
+	limiter *rate.Limiter
+	next    http.RoundTripper
+}
+
+// RoundTrip waits for a token when one becomes available before the request
+// deadline and fails fast with a RateLimitError otherwise.
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	now := time.Now()
+
+	reservation := t.limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		throttledRequests.WithLabelValues(t.vendor, "rejected").Inc()
+		return nil, &RateLimitError{Vendor: t.vendor}
+	}
+
+	delay := reservation.DelayFrom(now)
+	if delay > 0 {
+		if deadline, ok := ctx.Deadline(); ok && now.Add(delay).After(deadline) {
+			reservation.CancelAt(now)
+			throttledRequests.WithLabelValues(t.vendor, "rejected").Inc()
+			return nil, &RateLimitError{Vendor: t.vendor, Wait: delay}
+		}
+
+		throttledRequests.WithLabelValues(t.vendor, "delayed").Inc()
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			reservation.Cancel()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,98 @@
+package webclients
+
+import (
+	"context"
+	"crypto-aggregator-service/internal/models"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func newCountingServer(calls *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func doWithTimeout(client *http.Client, url string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func TestWithRateLimit_WaitsWithinDeadline(t *testing.T) {
+	var calls atomic.Int32
+	server := newCountingServer(&calls)
+	defer server.Close()
+
+	// One token every 50ms, no burst beyond the first request.
+	client := NewClient(time.Second, WithRateLimit("wait-vendor", rate.NewLimiter(rate.Every(50*time.Millisecond), 1)))
+	delayed := testutil.ToFloat64(throttledRequests.WithLabelValues("wait-vendor", "delayed"))
+
+	start := time.Now()
+	require.NoError(t, doWithTimeout(client, server.URL, time.Second))
+	require.NoError(t, doWithTimeout(client, server.URL, time.Second))
+
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, delayed+1, testutil.ToFloat64(throttledRequests.WithLabelValues("wait-vendor", "delayed")))
+}
+
+func TestWithRateLimit_FailsFastPastDeadline(t *testing.T) {
+	var calls atomic.Int32
+	server := newCountingServer(&calls)
+	defer server.Close()
+
+	client := NewClient(time.Second, WithRateLimit("busy-vendor", rate.NewLimiter(rate.Every(time.Minute), 1)))
+	rejected := testutil.ToFloat64(throttledRequests.WithLabelValues("busy-vendor", "rejected"))
+
+	require.NoError(t, doWithTimeout(client, server.URL, time.Second))
+
+	start := time.Now()
+	err := doWithTimeout(client, server.URL, 100*time.Millisecond)
+
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 50*time.Millisecond, "should not wait for a token it cannot get in time")
+	assert.ErrorIs(t, err, models.ErrRateLimited)
+	var rlErr *RateLimitError
+	require.True(t, errors.As(err, &rlErr))
+	assert.Equal(t, "busy-vendor", rlErr.Vendor)
+	assert.Positive(t, rlErr.Wait)
+
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, rejected+1, testutil.ToFloat64(throttledRequests.WithLabelValues("busy-vendor", "rejected")))
+}
+
+func TestWithRateLimit_RejectedTokensAreReturned(t *testing.T) {
+	var calls atomic.Int32
+	server := newCountingServer(&calls)
+	defer server.Close()
+
+	limiter := rate.NewLimiter(rate.Every(100*time.Millisecond), 1)
+	client := NewClient(time.Second, WithRateLimit("refund-vendor", limiter))
+
+	require.NoError(t, doWithTimeout(client, server.URL, time.Second))
+	require.Error(t, doWithTimeout(client, server.URL, 10*time.Millisecond))
+
+	// The cancelled reservation does not push the next slot further away.
+	start := time.Now()
+	require.NoError(t, doWithTimeout(client, server.URL, time.Second))
+	assert.Less(t, time.Since(start), 150*time.Millisecond)
+}
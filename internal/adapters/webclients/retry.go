@@ -0,0 +1,182 @@
+package webclients
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var retriedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "vendor_retried_requests_total",
+	Help: "Outbound vendor requests sent again after a retryable failure.",
+}, []string{"vendor", "reason"}) // reason: network | status code
+
+// RetryPolicy tells which failed requests are sent again and how long to
+// wait in between. Zero values take the defaults.
+type RetryPolicy struct {
+	MaxAttempts int           // total attempts, including the first one (3)
+	BaseDelay   time.Duration // backoff before the second attempt, doubled after (100ms)
+	MaxDelay    time.Duration // backoff cap; a longer Retry-After ends the retries (2s)
+	// RetryStatuses are the response codes worth another attempt
+	// (429, 500, 502, 503 and 504).
+	RetryStatuses []int
+}
+
+var defaultRetryStatuses = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// WithRetry sends failed requests of one vendor again following policy.
+// Apply it after WithRateLimit so every attempt takes its own token.
+func WithRetry(vendor string, policy RetryPolicy) Option {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 3
+	}
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = 100 * time.Millisecond
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = 2 * time.Second
+	}
+	if len(policy.RetryStatuses) == 0 {
+		policy.RetryStatuses = defaultRetryStatuses
+	}
+	return func(c *http.Client) {
+		c.Transport = &retryTransport{vendor: vendor, policy: policy, next: c.Transport}
+	}
+}
+
+type retryTransport struct {
+	vendor string
+	policy RetryPolicy
+	next   http.RoundTripper
+}
+
+// RoundTrip retries network errors and retryable statuses with exponential
+// backoff and jitter. A retry is only scheduled when it can start before the
+// request deadline; otherwise the last response or error is returned as is.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if !replayable(req) {
+		return t.next.RoundTrip(req)
+	}
+
+	for attempt := 1; ; attempt++ {
+		attemptReq, err := rewind(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := t.next.RoundTrip(attemptReq)
+		reason, retryable := t.retryable(ctx, resp, err)
+		if !retryable || attempt >= t.policy.MaxAttempts {
+			return resp, err
+		}
+
+		delay, ok := t.backoff(attempt, resp)
+		if !ok {
+			return resp, err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+			return resp, err
+		}
+		if resp != nil {
+			// Let the connection be reused by the next attempt
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		retriedRequests.WithLabelValues(t.vendor, reason).Inc()
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryable reports whether the outcome of an attempt is worth another one
+// and labels why.
+func (t *retryTransport) retryable(ctx context.Context, resp *http.Response, err error) (string, bool) {
+	if err != nil {
+		// Neither a caller that gave up nor the client-side limiter get better
+		// by trying again.
+		var rlErr *RateLimitError
+		if ctx.Err() != nil || errors.As(err, &rlErr) || errors.Is(err, ErrNoFixture) {
+			return "", false
+		}
+		return "network", true
+	}
+	for _, status := range t.policy.RetryStatuses {
+		if resp.StatusCode == status {
+			return strconv.Itoa(status), true
+		}
+	}
+	return "", false
+}
+
+// backoff is the wait before the attempt following attempt. A Retry-After
+// header wins over the computed backoff; when it asks for more than MaxDelay
+// there is no retry at all.
+func (t *retryTransport) backoff(attempt int, resp *http.Response) (time.Duration, bool) {
+	if resp != nil {
+		if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return after, after <= t.policy.MaxDelay
+		}
+	}
+
+	delay := t.policy.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > t.policy.MaxDelay {
+		delay = t.policy.MaxDelay
+	}
+	// Equal jitter: half fixed, half random, so callers never retry in lockstep
+	half := delay / 2
+	return half + rand.N(half+1), true
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+// replayable reports whether the request can be sent more than once.
+func replayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// rewind returns the request for an attempt, with a fresh body from GetBody
+// after the first one.
+func rewind(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 1 || req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = body
+	return clone, nil
+}
@@ -0,0 +1,175 @@
+package webclients
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFlakyServer answers with the given statuses in order, then 200.
+func newFlakyServer(calls *atomic.Int32, header http.Header, statuses ...int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		if n <= len(statuses) {
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func statusOf(t *testing.T, client *http.Client, url string, timeout time.Duration) int {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if timeout > 0 {
+		client.Timeout = timeout
+	}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestWithRetry_RetriesRetryableStatuses(t *testing.T) {
+	var calls atomic.Int32
+	server := newFlakyServer(&calls, nil, http.StatusServiceUnavailable, http.StatusBadGateway)
+	defer server.Close()
+
+	client := NewClient(time.Second, WithRetry("flaky-vendor", RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}))
+	retried := testutil.ToFloat64(retriedRequests.WithLabelValues("flaky-vendor", "503"))
+
+	assert.Equal(t, http.StatusOK, statusOf(t, client, server.URL, 0))
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, retried+1, testutil.ToFloat64(retriedRequests.WithLabelValues("flaky-vendor", "503")))
+}
+
+func TestWithRetry_StopsAtMaxAttempts(t *testing.T) {
+	var calls atomic.Int32
+	server := newFlakyServer(&calls, nil, 500, 500, 500, 500)
+	defer server.Close()
+
+	client := NewClient(time.Second, WithRetry("down-vendor", RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}))
+
+	assert.Equal(t, http.StatusInternalServerError, statusOf(t, client, server.URL, 0))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestWithRetry_DoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := newFlakyServer(&calls, nil, http.StatusNotFound)
+	defer server.Close()
+
+	client := NewClient(time.Second, WithRetry("missing-vendor", RetryPolicy{BaseDelay: time.Millisecond}))
+
+	assert.Equal(t, http.StatusNotFound, statusOf(t, client, server.URL, 0))
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestWithRetry_HonorsRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	server := newFlakyServer(&calls, http.Header{"Retry-After": {"1"}}, http.StatusTooManyRequests)
+	defer server.Close()
+
+	client := NewClient(3*time.Second, WithRetry("busy-vendor", RetryPolicy{BaseDelay: time.Millisecond, MaxDelay: 2 * time.Second}))
+
+	start := time.Now()
+	assert.Equal(t, http.StatusOK, statusOf(t, client, server.URL, 0))
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestWithRetry_RetryAfterBeyondMaxDelayIsNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	server := newFlakyServer(&calls, http.Header{"Retry-After": {"60"}}, http.StatusTooManyRequests)
+	defer server.Close()
+
+	client := NewClient(time.Second, WithRetry("slow-vendor", RetryPolicy{MaxDelay: time.Second}))
+
+	assert.Equal(t, http.StatusTooManyRequests, statusOf(t, client, server.URL, 0))
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestWithRetry_NeverOutlivesTheDeadline(t *testing.T) {
+	var calls atomic.Int32
+	server := newFlakyServer(&calls, nil, 503, 503, 503, 503, 503)
+	defer server.Close()
+
+	client := NewClient(time.Second, WithRetry("late-vendor", RetryPolicy{MaxAttempts: 5, BaseDelay: 400 * time.Millisecond}))
+
+	start := time.Now()
+	assert.Equal(t, http.StatusServiceUnavailable, statusOf(t, client, server.URL, 150*time.Millisecond))
+	assert.Less(t, time.Since(start), 150*time.Millisecond, "a backoff past the deadline is not started")
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestWithRetry_RetriesNetworkErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := newFlakyServer(&calls, nil)
+	defer server.Close()
+
+	failures := 1
+	flaky := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if failures > 0 {
+			failures--
+			return nil, io.ErrUnexpectedEOF
+		}
+		return http.DefaultTransport.RoundTrip(req)
+	})
+	client := &http.Client{Timeout: time.Second, Transport: flaky}
+	WithRetry("reset-vendor", RetryPolicy{BaseDelay: time.Millisecond})(client)
+
+	assert.Equal(t, http.StatusOK, statusOf(t, client, server.URL, 0))
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestWithRetry_ReplaysRequestBody(t *testing.T) {
+	var bodies []string
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(time.Second, WithRetry("post-vendor", RetryPolicy{BaseDelay: time.Millisecond}))
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"payload", "payload"}, bodies)
+}
+
+func TestRetryAfter(t *testing.T) {
+	d, ok := retryAfter("3")
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, d)
+
+	d, ok = retryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	assert.True(t, ok)
+	assert.Greater(t, d, 59*time.Minute)
+
+	_, ok = retryAfter("soon")
+	assert.False(t, ok)
+	_, ok = retryAfter("")
+	assert.False(t, ok)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
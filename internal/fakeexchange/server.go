@@ -0,0 +1,293 @@
+// Package fakeexchange emulates the Bitso v3 public REST endpoints used by the
+// service so it can run without internet access.
+package fakeexchange
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/goccy/go-json"
+)
+
+// Config drives prices and injected failures.
+type Config struct {
+	Seed int64
+	// Books maps every served book to its starting last price.
+	Books map[string]float64
+	// Volatility is the standard deviation of each random walk step as a
+	// fraction of the price. Zero serves fixed prices.
+	Volatility float64
+	// Delay holds every reply this long.
+	Delay time.Duration
+	// RateLimitRate and ErrorRate are the share of requests answered with
+	// 429 and 500 respectively.
+	RateLimitRate float64
+	ErrorRate     float64
+}
+
+// DefaultBooks are the books served when none are configured.
+func DefaultBooks() map[string]float64 {
+	return map[string]float64{
+		"btc_mxn": 1118000,
+		"btc_usd": 65000,
+		"eth_mxn": 58480,
+		"eth_usd": 3400,
+		"xrp_mxn": 8.94,
+		"usd_mxn": 17.2,
+	}
+}
+
+// Server is the fake exchange. It is safe for concurrent use.
+type Server struct {
+	mu     sync.Mutex
+	cfg    Config
+	rng    *rand.Rand
+	prices map[string]float64
+	forced int // status returned to every request, 0 when off
+}
+
+func New(cfg Config) *Server {
+	if len(cfg.Books) == 0 {
+		cfg.Books = DefaultBooks()
+	}
+	prices := make(map[string]float64, len(cfg.Books))
+	for book, price := range cfg.Books {
+		prices[book] = price
+	}
+	return &Server{cfg: cfg, rng: rand.New(rand.NewSource(cfg.Seed)), prices: prices}
+}
+
+// Handler routes the Bitso endpoints plus the /fake/mode switch.
+func (s *Server) Handler() http.Handler {
+	router := chi.NewRouter()
+	router.Post("/fake/mode", s.setMode)
+
+	router.Group(func(r chi.Router) {
+		r.Use(s.inject)
+		r.Get("/v3/ticker", s.ticker)
+		r.Get("/v3/ticker/", s.ticker)
+		r.Get("/v3/available_books", s.availableBooks)
+		r.Get("/v3/available_books/", s.availableBooks)
+	})
+	return router
+}
+
+type ticker struct {
+	Book      string `json:"book"`
+	Last      string `json:"last"`
+	Bid       string `json:"bid"`
+	Ask       string `json:"ask"`
+	High      string `json:"high"`
+	Low       string `json:"low"`
+	VWAP      string `json:"vwap"`
+	Volume    string `json:"volume"`
+	CreatedAt string `json:"created_at"`
+}
+
+type availableBook struct {
+	Book          string `json:"book"`
+	MinimumAmount string `json:"minimum_amount"`
+	MaximumAmount string `json:"maximum_amount"`
+	MinimumPrice  string `json:"minimum_price"`
+	MaximumPrice  string `json:"maximum_price"`
+	MinimumValue  string `json:"minimum_value"`
+	MaximumValue  string `json:"maximum_value"`
+}
+
+// ticker serves every book, or a single one with ?book=. Each call advances
+// the random walk one step.
+func (s *Server) ticker(w http.ResponseWriter, r *http.Request) {
+	prices := s.step()
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	books := sortedBooks(prices)
+	if book := r.URL.Query().Get("book"); book != "" {
+		if _, ok := prices[book]; !ok {
+			writeError(w, http.StatusBadRequest, "0301", "Unknown OrderBook "+book)
+			return
+		}
+		books = []string{book}
+	}
+
+	payload := make([]ticker, 0, len(books))
+	for _, book := range books {
+		last := prices[book]
+		payload = append(payload, ticker{
+			Book:      book,
+			Last:      formatPrice(last),
+			Bid:       formatPrice(last * 0.999),
+			Ask:       formatPrice(last * 1.001),
+			High:      formatPrice(last * 1.02),
+			Low:       formatPrice(last * 0.98),
+			VWAP:      formatPrice(last),
+			Volume:    "1234.5",
+			CreatedAt: now,
+		})
+	}
+
+	if len(payload) == 1 && r.URL.Query().Get("book") != "" {
+		writeJSON(w, http.StatusOK, map[string]any{"success": true, "payload": payload[0]})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "payload": payload})
+}
+
+func (s *Server) availableBooks(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	books := sortedBooks(s.prices)
+	s.mu.Unlock()
+
+	payload := make([]availableBook, 0, len(books))
+	for _, book := range books {
+		payload = append(payload, availableBook{
+			Book:          book,
+			MinimumAmount: "0.00001",
+			MaximumAmount: "1000000",
+			MinimumPrice:  "0.01",
+			MaximumPrice:  "100000000",
+			MinimumValue:  "10",
+			MaximumValue:  "100000000",
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "payload": payload})
+}
+
+// setMode switches failures and delay at runtime:
+// POST /fake/mode?status=429&delay=2s. status=0 (or 200) turns forced
+// failures off.
+func (s *Server) setMode(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if v := q.Get("status"); v != "" {
+		status, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "invalid status", http.StatusBadRequest)
+			return
+		}
+		if status == http.StatusOK {
+			status = 0
+		}
+		s.forced = status
+	}
+	if v := q.Get("delay"); v != "" {
+		delay, err := time.ParseDuration(v)
+		if err != nil {
+			http.Error(w, "invalid delay", http.StatusBadRequest)
+			return
+		}
+		s.cfg.Delay = delay
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"status": s.forced, "delay": s.cfg.Delay.String()})
+}
+
+// inject applies the configured delay and failures before the real handler.
+func (s *Server) inject(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		delay, status := s.cfg.Delay, s.forced
+		if status == 0 {
+			switch roll := s.rng.Float64(); {
+			case roll < s.cfg.RateLimitRate:
+				status = http.StatusTooManyRequests
+			case roll < s.cfg.RateLimitRate+s.cfg.ErrorRate:
+				status = http.StatusInternalServerError
+			}
+		}
+		s.mu.Unlock()
+
+		if delay > 0 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(delay):
+			}
+		}
+
+		switch status {
+		case 0:
+			next.ServeHTTP(w, r)
+		case http.StatusTooManyRequests:
+			w.Header().Set("Retry-After", "1")
+			writeError(w, status, "0201", "Too many requests")
+		default:
+			writeError(w, status, "0101", "Unknown error")
+		}
+	})
+}
+
+// step advances every book one random walk step and returns a snapshot.
+func (s *Server) step() map[string]float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := make(map[string]float64, len(s.prices))
+	for _, book := range sortedBooks(s.prices) {
+		if s.cfg.Volatility > 0 {
+			s.prices[book] *= 1 + s.cfg.Volatility*s.rng.NormFloat64()
+		}
+		snapshot[book] = s.prices[book]
+	}
+	return snapshot
+}
+
+// sortedBooks keeps the walk reproducible for a seed, map order is random.
+func sortedBooks(prices map[string]float64) []string {
+	books := make([]string, 0, len(prices))
+	for book := range prices {
+		books = append(books, book)
+	}
+	sort.Strings(books)
+	return books
+}
+
+// ParseBooks reads "btc_mxn=1100000,eth_mxn=58000".
+func ParseBooks(spec string) (map[string]float64, error) {
+	books := make(map[string]float64)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		book, price, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid book %q, want book=price", pair)
+		}
+		v, err := strconv.ParseFloat(price, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid price for %s: %w", book, err)
+		}
+		books[strings.ToLower(strings.TrimSpace(book))] = v
+	}
+	return books, nil
+}
+
+// formatPrice mimics Bitso: cents for fiat-sized prices, more digits below 1.
+func formatPrice(v float64) string {
+	if v < 1 {
+		return strconv.FormatFloat(v, 'f', 6, 64)
+	}
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]any{
+		"success": false,
+		"error":   map[string]string{"code": code, "message": message},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
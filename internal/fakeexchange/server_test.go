@@ -0,0 +1,181 @@
+package fakeexchange
+
+import (
+	"context"
+	"crypto-aggregator-service/internal/models"
+	"crypto-aggregator-service/internal/repositories"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tickerResp struct {
+	Success bool     `json:"success"`
+	Payload []ticker `json:"payload"`
+}
+
+func getTicker(t *testing.T, server *httptest.Server) tickerResp {
+	t.Helper()
+	resp, err := http.Get(server.URL + "/v3/ticker/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body tickerResp
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	return body
+}
+
+func TestServer_Ticker_AllBooks(t *testing.T) {
+	server := httptest.NewServer(New(Config{Books: map[string]float64{"btc_mxn": 1000000, "eth_mxn": 50000}}).Handler())
+	defer server.Close()
+
+	body := getTicker(t, server)
+
+	assert.True(t, body.Success)
+	require.Len(t, body.Payload, 2)
+	assert.Equal(t, "btc_mxn", body.Payload[0].Book)
+	assert.Equal(t, "1000000.00", body.Payload[0].Last)
+	assert.Equal(t, "eth_mxn", body.Payload[1].Book)
+	assert.Equal(t, "50000.00", body.Payload[1].Last)
+}
+
+func TestServer_Ticker_SingleBook(t *testing.T) {
+	server := httptest.NewServer(New(Config{}).Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/v3/ticker/?book=usd_mxn")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var body struct {
+		Success bool   `json:"success"`
+		Payload ticker `json:"payload"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "usd_mxn", body.Payload.Book)
+	assert.Equal(t, "17.20", body.Payload.Last)
+
+	unknown, err := http.Get(server.URL + "/v3/ticker/?book=doge_mxn")
+	require.NoError(t, err)
+	defer unknown.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, unknown.StatusCode)
+}
+
+func TestServer_Ticker_SeededRandomWalk(t *testing.T) {
+	run := func(seed int64) []string {
+		server := httptest.NewServer(New(Config{Seed: seed, Volatility: 0.01, Books: map[string]float64{"btc_mxn": 1000000}}).Handler())
+		defer server.Close()
+
+		var lasts []string
+		for i := 0; i < 5; i++ {
+			lasts = append(lasts, getTicker(t, server).Payload[0].Last)
+		}
+		return lasts
+	}
+
+	a, b := run(1), run(1)
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a[0], a[1])
+	assert.NotEqual(t, a, run(2))
+}
+
+func TestServer_AvailableBooks(t *testing.T) {
+	server := httptest.NewServer(New(Config{Books: map[string]float64{"btc_mxn": 1, "eth_mxn": 1}}).Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/v3/available_books/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var body struct {
+		Success bool            `json:"success"`
+		Payload []availableBook `json:"payload"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.True(t, body.Success)
+	require.Len(t, body.Payload, 2)
+	assert.Equal(t, "btc_mxn", body.Payload[0].Book)
+}
+
+func TestServer_InjectedFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    Config
+		status int
+	}{
+		{"rate limited", Config{RateLimitRate: 1}, http.StatusTooManyRequests},
+		{"server error", Config{ErrorRate: 1}, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(New(tt.cfg).Handler())
+			defer server.Close()
+
+			resp, err := http.Get(server.URL + "/v3/ticker/")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestServer_ModeSwitch(t *testing.T) {
+	server := httptest.NewServer(New(Config{}).Handler())
+	defer server.Close()
+
+	setMode := func(query string) {
+		resp, err := http.Post(server.URL+"/fake/mode?"+query, "application/json", nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	status := func() int {
+		resp, err := http.Get(server.URL + "/v3/ticker/")
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	setMode("status=429")
+	assert.Equal(t, http.StatusTooManyRequests, status())
+	setMode("status=500")
+	assert.Equal(t, http.StatusInternalServerError, status())
+	setMode("status=200&delay=50ms")
+
+	start := time.Now()
+	assert.Equal(t, http.StatusOK, status())
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestServer_ServesBitsoProvider(t *testing.T) {
+	server := httptest.NewServer(New(Config{Books: map[string]float64{"btc_mxn": 1100000, "btc_usd": 65000}}).Handler())
+	defer server.Close()
+
+	bitso := repositories.NewBitsoCryptoProvider(server.Client())
+	bitso.BaseURL = server.URL
+
+	price, err := bitso.GetPrice(context.Background(), "BTC")
+	require.NoError(t, err)
+	assert.Equal(t, 1100000.0, price.MXN.Float64())
+	assert.Equal(t, 65000.0, price.USD.Float64())
+
+	_, err = bitso.GetPrice(context.Background(), "DOGE")
+	assert.ErrorIs(t, err, models.ErrUnsupportedSymbol)
+}
+
+func TestParseBooks(t *testing.T) {
+	books, err := ParseBooks("btc_mxn=1100000, ETH_MXN=58000")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"btc_mxn": 1100000, "eth_mxn": 58000}, books)
+
+	_, err = ParseBooks("btc_mxn")
+	assert.Error(t, err)
+	_, err = ParseBooks("btc_mxn=abc")
+	assert.Error(t, err)
+}
@@ -0,0 +1,11 @@
+package models
+
+// Asset is the display metadata of a ticker.
+type Asset struct {
+	Symbol string `json:"symbol"`
+	Name   string `json:"name"` // e.g. "Bitcoin"
+	// Decimals is how many decimal places prices are rounded to.
+	Decimals int32  `json:"decimals"`
+	Category string `json:"category,omitempty"` // e.g. "layer1", "stablecoin"
+	IconURL  string `json:"icon_url,omitempty"`
+}
@@ -1,18 +1,278 @@
 package models
 
 import (
+	"bytes"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/goccy/go-json"
 )
 
 type Ticker string
 
+// Money holds the exact price of an asset in several fiat currencies. USD
+// and MXN keep their own fields and JSON keys for existing clients; any other
+// currency lives in Quotes and renders as an extra lower-case key.
 type Money struct {
-	USD float64 `json:"usd"`
-	MXN float64 `json:"mxn"`
+	USD Decimal `json:"usd"`
+	MXN Decimal `json:"mxn"`
+	// Quotes holds every other currency, keyed by upper-case ISO code.
+	Quotes map[string]Decimal `json:"-"`
+	// Derived lists the currencies converted through an FX rate instead of
+	// being quoted natively by the vendor.
+	Derived []string `json:"derived,omitempty"`
+	// Volume is the vendor's 24h traded volume in the base asset. It only
+	// weights VWAP aggregation and is not rendered, so a float is enough.
+	Volume float64 `json:"-"`
+	// Market is the book data behind each quoted currency, keyed like the
+	// JSON price fields ("usd", "mxn"). It is copied to Model.Market.
+	Market map[string]MarketData `json:"-"`
+	// SourceTime is when the vendor says the price was set, zero when the
+	// vendor does not report it.
+	SourceTime time.Time `json:"-"`
+}
+
+// SetSourceTime records the vendor timestamp of a price, keeping the oldest
+// one: a price built from several books is as old as its oldest book.
+func (m *Money) SetSourceTime(t time.Time) {
+	if !t.IsZero() && (m.SourceTime.IsZero() || t.Before(m.SourceTime)) {
+		m.SourceTime = t
+	}
+}
+
+// MarketData is the market snapshot of one book. Fields the vendor does not
+// supply stay nil and render as null instead of a misleading zero.
+type MarketData struct {
+	Bid       *Decimal `json:"bid"`
+	Ask       *Decimal `json:"ask"`
+	Spread    *Decimal `json:"spread"` // ask - bid
+	High24h   *Decimal `json:"high_24h"`
+	Low24h    *Decimal `json:"low_24h"`
+	Volume24h *Decimal `json:"volume_24h"` // in the base asset
+	VWAP      *Decimal `json:"vwap"`
+	Change24h *Decimal `json:"change_24h"` // absolute, in the quote currency
+}
+
+// SetMarket stores the market data of currency, computing the spread when the
+// vendor sent bid and ask only. Empty data is not stored.
+func (m *Money) SetMarket(currency string, data MarketData) {
+	if data.Spread == nil && data.Bid != nil && data.Ask != nil {
+		spread := data.Ask.Sub(*data.Bid)
+		data.Spread = &spread
+	}
+	if data == (MarketData{}) {
+		return
+	}
+	if m.Market == nil {
+		m.Market = make(map[string]MarketData)
+	}
+	m.Market[strings.ToLower(currency)] = data
+}
+
+// DefaultCurrencies are quoted when neither the layout nor the component
+// picks its own, in display order.
+var DefaultCurrencies = []string{"USD", "MXN"}
+
+// Get returns the price for an ISO currency code and whether it is set.
+func (m Money) Get(currency string) (Decimal, bool) {
+	switch currency = strings.ToUpper(currency); currency {
+	case "USD":
+		return m.USD, !m.USD.IsZero()
+	case "MXN":
+		return m.MXN, !m.MXN.IsZero()
+	default:
+		v := m.Quotes[currency]
+		return v, !v.IsZero()
+	}
+}
+
+// Currencies lists the currencies with a price: USD and MXN first, then the
+// rest alphabetically.
+func (m Money) Currencies() []string {
+	var currencies []string
+	for _, c := range DefaultCurrencies {
+		if _, ok := m.Get(c); ok {
+			currencies = append(currencies, c)
+		}
+	}
+	extra := make([]string, 0, len(m.Quotes))
+	for c, v := range m.Quotes {
+		if !v.IsZero() {
+			extra = append(extra, c)
+		}
+	}
+	sort.Strings(extra)
+	return append(currencies, extra...)
+}
+
+// Only returns a copy restricted to currencies, dropping the prices, derived
+// flags and market data of every other currency.
+func (m Money) Only(currencies []string) Money {
+	out := Money{Volume: m.Volume, SourceTime: m.SourceTime}
+	for _, c := range currencies {
+		if v, ok := m.Get(c); ok {
+			out.Set(c, v)
+			if m.IsDerived(c) {
+				out.Derived = append(out.Derived, strings.ToUpper(c))
+			}
+		}
+		if data, ok := m.Market[strings.ToLower(c)]; ok {
+			out.SetMarket(c, data)
+		}
+	}
+	return out
+}
+
+// IsDerived reports whether currency was converted rather than quoted.
+func (m Money) IsDerived(currency string) bool {
+	for _, c := range m.Derived {
+		if strings.EqualFold(c, currency) {
+			return true
+		}
+	}
+	return false
 }
+
+// Set stores a price by its ISO currency code.
+// It reports false when currency is not a three letter code.
+func (m *Money) Set(currency string, value Decimal) bool {
+	switch currency = strings.ToUpper(currency); {
+	case currency == "USD":
+		m.USD = value
+	case currency == "MXN":
+		m.MXN = value
+	case !IsCurrencyCode(currency):
+		return false
+	default:
+		if m.Quotes == nil {
+			m.Quotes = make(map[string]Decimal)
+		}
+		m.Quotes[currency] = value
+	}
+	return true
+}
+
+// Round rounds every price to scale decimal places. Market data keeps the
+// digits the vendor sent.
+func (m Money) Round(scale int32) Money {
+	m.USD, m.MXN = m.USD.Round(scale), m.MXN.Round(scale)
+	if len(m.Quotes) > 0 {
+		quotes := make(map[string]Decimal, len(m.Quotes))
+		for c, v := range m.Quotes {
+			quotes[c] = v.Round(scale)
+		}
+		m.Quotes = quotes
+	}
+	return m
+}
+
+// IsCurrencyCode reports whether code looks like an ISO 4217 code.
+func IsCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range strings.ToUpper(code) {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// MarshalJSON renders the legacy usd and mxn keys, always present, followed
+// by the other currencies and the derived list.
+func (m Money) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	write := func(key string, value any) error {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(`"` + key + `":`)
+		buf.Write(data)
+		return nil
+	}
+
+	if err := write("usd", m.USD); err != nil {
+		return nil, err
+	}
+	if err := write("mxn", m.MXN); err != nil {
+		return nil, err
+	}
+	for _, c := range m.Currencies() {
+		if c == "USD" || c == "MXN" {
+			continue
+		}
+		if err := write(strings.ToLower(c), m.Quotes[c]); err != nil {
+			return nil, err
+		}
+	}
+	if len(m.Derived) > 0 {
+		if err := write("derived", m.Derived); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON reads what MarshalJSON writes.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	*m = Money{}
+	for key, raw := range fields {
+		if key == "derived" {
+			if err := json.Unmarshal(raw, &m.Derived); err != nil {
+				return err
+			}
+			continue
+		}
+		var value Decimal
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return err
+		}
+		m.Set(key, value)
+	}
+	return nil
+}
+
 type Model struct {
 	Date         time.Time `json:"date"`
 	Name         string    `json:"name"`
 	TickerSymbol Ticker    `json:"ticker_symbol"`
 	Price        Money     `json:"price"`
+	// Decimals is the number of decimal places the prices were rounded to.
+	Decimals int32  `json:"decimals"`
+	Category string `json:"category,omitempty"`
+	IconURL  string `json:"icon_url,omitempty"`
+	// Market holds bid, ask and 24h statistics per currency when the vendor
+	// provides them. Aggregated and converted prices have none.
+	Market map[string]MarketData `json:"market,omitempty"`
+	// Vendors priced the model: one vendor, or every vendor aggregated.
+	Vendors []string `json:"vendors,omitempty"`
+	// SourceTime is the vendor timestamp of the price, null when the vendor
+	// does not report one. Aggregated prices take the oldest.
+	SourceTime *time.Time `json:"source_time"`
+	// FetchedAt is when the service received the price.
+	FetchedAt time.Time `json:"fetched_at"`
+	// Stale is computed on read: the price is older than the component max
+	// age, measured from SourceTime or, without it, FetchedAt.
+	Stale bool `json:"stale"`
+}
+
+// Age returns how old the price is at now.
+func (m Model) Age(now time.Time) time.Duration {
+	if m.SourceTime != nil {
+		return now.Sub(*m.SourceTime)
+	}
+	return now.Sub(m.FetchedAt)
 }
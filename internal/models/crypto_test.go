@@ -10,7 +10,7 @@ import (
 )
 
 func TestMoney_JSONSerialization(t *testing.T) {
-	m := Money{USD: 50000.50, MXN: 900000.75}
+	m := Money{USD: DecimalFromFloat(50000.50), MXN: DecimalFromFloat(900000.75)}
 
 	data, err := json.Marshal(m)
 	require.NoError(t, err)
@@ -19,8 +19,8 @@ func TestMoney_JSONSerialization(t *testing.T) {
 	err = json.Unmarshal(data, &decoded)
 	require.NoError(t, err)
 
-	assert.InDelta(t, m.USD, decoded.USD, 0.01)
-	assert.InDelta(t, m.MXN, decoded.MXN, 0.01)
+	assert.InDelta(t, m.USD.Float64(), decoded.USD.Float64(), 0.01)
+	assert.InDelta(t, m.MXN.Float64(), decoded.MXN.Float64(), 0.01)
 }
 
 func TestModel_JSONSerialization(t *testing.T) {
@@ -29,7 +29,7 @@ func TestModel_JSONSerialization(t *testing.T) {
 		Date:         now,
 		Name:         "Bitcoin",
 		TickerSymbol: Ticker("BTC"),
-		Price:        Money{USD: 50000.0, MXN: 900000.0},
+		Price:        Money{USD: DecimalFromFloat(50000.0), MXN: DecimalFromFloat(900000.0)},
 	}
 
 	data, err := json.Marshal(model)
@@ -41,11 +41,143 @@ func TestModel_JSONSerialization(t *testing.T) {
 
 	assert.Equal(t, model.Name, decoded.Name)
 	assert.Equal(t, model.TickerSymbol, decoded.TickerSymbol)
-	assert.InDelta(t, model.Price.USD, decoded.Price.USD, 0.01)
-	assert.InDelta(t, model.Price.MXN, decoded.Price.MXN, 0.01)
+	assert.InDelta(t, model.Price.USD.Float64(), decoded.Price.USD.Float64(), 0.01)
+	assert.InDelta(t, model.Price.MXN.Float64(), decoded.Price.MXN.Float64(), 0.01)
+}
+
+func TestModel_MarketRendersMissingFieldsAsNull(t *testing.T) {
+	bid, ask := DecimalFromFloat(100), DecimalFromFloat(101.5)
+	var price Money
+	price.SetMarket("USD", MarketData{Bid: &bid, Ask: &ask})
+	price.SetMarket("MXN", MarketData{})
+
+	data, err := json.Marshal(Model{Price: price, Market: price.Market})
+	require.NoError(t, err)
+
+	var raw struct {
+		Market map[string]map[string]any `json:"market"`
+	}
+	require.NoError(t, json.Unmarshal(data, &raw))
+	usd := raw.Market["usd"]
+	assert.InDelta(t, 1.5, usd["spread"], 0.001)
+	assert.Contains(t, usd, "high_24h")
+	assert.Nil(t, usd["high_24h"])
+	assert.NotContains(t, raw.Market, "mxn", "empty market data is not stored")
+}
+
+func TestModel_MarketOmittedWhenUnknown(t *testing.T) {
+	data, err := json.Marshal(Model{Price: Money{USD: DecimalFromFloat(1)}})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "market")
+}
+
+func TestModel_Age(t *testing.T) {
+	fetched := time.Date(2026, 10, 18, 15, 4, 10, 0, time.UTC)
+	source := fetched.Add(-10 * time.Second)
+	now := fetched.Add(5 * time.Second)
+
+	assert.Equal(t, 5*time.Second, Model{FetchedAt: fetched}.Age(now))
+	assert.Equal(t, 15*time.Second, Model{FetchedAt: fetched, SourceTime: &source}.Age(now))
 }
 
 func TestTicker_StringConversion(t *testing.T) {
 	ticker := Ticker("BTC")
 	assert.Equal(t, "BTC", string(ticker))
 }
+
+func TestComponent_Symbol(t *testing.T) {
+	assert.Equal(t, "ETH", Component{Component: "crypto_eth"}.Symbol())
+	assert.Equal(t, "BTC", Component{Component: "unknown"}.Symbol())
+}
+
+func TestMoney_Set(t *testing.T) {
+	var m Money
+
+	assert.True(t, m.Set("usd", DecimalFromFloat(10.5)))
+	assert.True(t, m.Set("MXN", DecimalFromFloat(180.0)))
+	assert.True(t, m.Set("eur", DecimalFromFloat(9.0)))
+	assert.False(t, m.Set("EURO", DecimalFromFloat(9.0)))
+	assert.False(t, m.Set("", DecimalFromFloat(9.0)))
+
+	assert.InDelta(t, 10.5, m.USD.Float64(), 0.001)
+	assert.InDelta(t, 180.0, m.MXN.Float64(), 0.001)
+	assert.InDelta(t, 9.0, m.Quotes["EUR"].Float64(), 0.001)
+	assert.Equal(t, []string{"USD", "MXN", "EUR"}, m.Currencies())
+}
+
+func TestMoney_JSONKeepsLegacyKeysAndAddsCurrencies(t *testing.T) {
+	m := Money{USD: DecimalFromFloat(1), Derived: []string{"BRL"}}
+	m.Set("BRL", DecimalFromFloat(5.5))
+	m.Set("ARS", DecimalFromFloat(900))
+
+	data, err := json.Marshal(m)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"usd":1,"mxn":0,"ars":900,"brl":5.5,"derived":["BRL"]}`, string(data))
+
+	var decoded Money
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, m.Quotes, decoded.Quotes)
+	assert.Equal(t, []string{"BRL"}, decoded.Derived)
+}
+
+func TestMoney_Only(t *testing.T) {
+	bid := DecimalFromFloat(1)
+	m := Money{USD: DecimalFromFloat(1), MXN: DecimalFromFloat(17), Derived: []string{"MXN", "EUR"}}
+	m.Set("EUR", DecimalFromFloat(0.9))
+	m.SetMarket("USD", MarketData{Bid: &bid})
+
+	only := m.Only([]string{"EUR", "USD"})
+
+	assert.Equal(t, []string{"USD", "EUR"}, only.Currencies())
+	assert.Equal(t, []string{"EUR"}, only.Derived)
+	assert.Contains(t, only.Market, "usd")
+}
+
+func TestSymbolErrors_Error(t *testing.T) {
+	err := SymbolErrors{
+		"XRP": ErrUnsupportedSymbol,
+		"ETH": ErrRateLimited,
+	}
+
+	assert.Equal(t, "2 symbols failed: ETH: provider rate limit exceeded | XRP: symbol not supported by provider", err.Error())
+}
+
+func TestMoney_Get(t *testing.T) {
+	m := Money{USD: DecimalFromFloat(10.5)}
+
+	usd, ok := m.Get("usd")
+	assert.True(t, ok)
+	assert.InDelta(t, 10.5, usd.Float64(), 0.001)
+
+	_, ok = m.Get("MXN")
+	assert.False(t, ok, "zero prices are reported as missing")
+
+	_, ok = m.Get("EUR")
+	assert.False(t, ok)
+
+	m.Set("EUR", DecimalFromFloat(9.5))
+	eur, ok := m.Get("eur")
+	assert.True(t, ok)
+	assert.InDelta(t, 9.5, eur.Float64(), 0.001)
+}
+
+func TestMoney_DerivedOmittedWhenEmpty(t *testing.T) {
+	data, err := json.Marshal(Money{USD: DecimalFromFloat(1), MXN: DecimalFromFloat(17)})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "derived")
+
+	data, err = json.Marshal(Money{USD: DecimalFromFloat(1), MXN: DecimalFromFloat(17), Derived: []string{"MXN"}})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"derived":["MXN"]`)
+}
+
+func TestParseAggregation(t *testing.T) {
+	for _, name := range []string{"", "median", "trimmed_mean", "vwap"} {
+		s, err := ParseAggregation(name)
+		require.NoError(t, err)
+		assert.Equal(t, AggregationStrategy(name), s)
+	}
+
+	_, err := ParseAggregation("mode")
+	assert.Error(t, err)
+}
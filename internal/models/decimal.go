@@ -0,0 +1,223 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DefaultScale is the number of decimal places kept for assets without a
+// configured scale.
+const DefaultScale = 8
+
+// divisionScale is the number of decimal places kept by Quo, enough for a
+// mean or a cross FX rate to survive rounding to any asset scale.
+const divisionScale = 18
+
+// Bounds of ParseDecimal. Vendor input is untrusted: an exponent such as
+// 1e-2147483647 would otherwise allocate without limit on Round or String.
+const (
+	maxDecimalDigits   = 64 // significant digits of the literal
+	maxDecimalExponent = 64 // absolute value of the e/E exponent
+	maxDecimalScale    = 36 // digits after the point once the exponent applies
+)
+
+// Decimal is an exact decimal number: an integer coefficient and the count of
+// digits after the point. Prices parsed from vendor strings keep every digit,
+// sums and products never round, and Round applies an asset scale when a
+// result has to be stored. The zero value is 0 and values are immutable.
+type Decimal struct {
+	coef  *big.Int // nil means 0
+	scale int32    // value = coef / 10^scale
+}
+
+// ParseDecimal reads a decimal literal such as "0.52340000" or "1.5e-3". It
+// rejects literals with more than 64 digits, an exponent beyond ±64 or more
+// than 36 decimal places.
+func ParseDecimal(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	mantissa, exp := s, int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+		}
+		mantissa, exp = s[:i], e
+		if exp < -maxDecimalExponent || exp > maxDecimalExponent {
+			return Decimal{}, fmt.Errorf("decimal %q out of range", s)
+		}
+	}
+
+	intPart, fracPart, _ := strings.Cut(mantissa, ".")
+	digits := intPart + fracPart
+	if digits == "" || digits == "-" || digits == "+" || strings.ContainsAny(digits[1:], "+-") {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	if len(strings.TrimLeft(digits, "+-")) > maxDecimalDigits {
+		return Decimal{}, fmt.Errorf("decimal %q out of range", s)
+	}
+	coef, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	scale := int64(len(fracPart)) - exp
+	if scale > maxDecimalScale {
+		return Decimal{}, fmt.Errorf("decimal %q out of range", s)
+	}
+	if scale < 0 {
+		coef.Mul(coef, pow10(-scale))
+		scale = 0
+	}
+	return Decimal{coef: coef, scale: int32(scale)}, nil
+}
+
+// MustParseDecimal is ParseDecimal for constants; it panics on bad input.
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// NewDecimalFromFloat converts f through its shortest decimal representation,
+// so 0.1 becomes exactly 0.1 rather than the nearest binary fraction. NaN,
+// infinities and values beyond ParseDecimal's bounds are an error.
+func NewDecimalFromFloat(f float64) (Decimal, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Decimal{}, fmt.Errorf("invalid decimal %v", f)
+	}
+	return ParseDecimal(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+// DecimalFromFloat is NewDecimalFromFloat for values known to be valid, such
+// as constants or values checked when they were loaded; anything it would
+// reject becomes zero.
+func DecimalFromFloat(f float64) Decimal {
+	d, err := NewDecimalFromFloat(f)
+	if err != nil {
+		return Decimal{}
+	}
+	return d
+}
+
+// DecimalFromInt converts an integer.
+func DecimalFromInt(i int64) Decimal {
+	return Decimal{coef: big.NewInt(i)}
+}
+
+func pow10(n int64) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(n), nil)
+}
+
+func (d Decimal) c() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return d.coef
+}
+
+// rescale returns the coefficient of d at a larger scale.
+func (d Decimal) rescale(scale int32) *big.Int {
+	if scale == d.scale {
+		return d.c()
+	}
+	return new(big.Int).Mul(d.c(), pow10(int64(scale-d.scale)))
+}
+
+func (d Decimal) Add(o Decimal) Decimal {
+	scale := max(d.scale, o.scale)
+	return Decimal{coef: new(big.Int).Add(d.rescale(scale), o.rescale(scale)), scale: scale}
+}
+
+func (d Decimal) Sub(o Decimal) Decimal {
+	scale := max(d.scale, o.scale)
+	return Decimal{coef: new(big.Int).Sub(d.rescale(scale), o.rescale(scale)), scale: scale}
+}
+
+func (d Decimal) Mul(o Decimal) Decimal {
+	return Decimal{coef: new(big.Int).Mul(d.c(), o.c()), scale: d.scale + o.scale}
+}
+
+// Quo returns d / o rounded to 18 decimal places. It panics when o is zero.
+func (d Decimal) Quo(o Decimal) Decimal {
+	// d/o = (d.coef * 10^(divisionScale + o.scale - d.scale + 1) / o.coef) / 10^(divisionScale+1)
+	shift := int64(divisionScale) + 1 + int64(o.scale) - int64(d.scale)
+	num := d.c()
+	if shift >= 0 {
+		num = new(big.Int).Mul(num, pow10(shift))
+	} else {
+		num = new(big.Int).Quo(num, pow10(-shift))
+	}
+	q := new(big.Int).Quo(num, o.c())
+	return Decimal{coef: q, scale: divisionScale + 1}.Round(divisionScale)
+}
+
+// Cmp compares d and o, returning -1, 0 or +1.
+func (d Decimal) Cmp(o Decimal) int {
+	scale := max(d.scale, o.scale)
+	return d.rescale(scale).Cmp(o.rescale(scale))
+}
+
+func (d Decimal) Sign() int    { return d.c().Sign() }
+func (d Decimal) IsZero() bool { return d.Sign() == 0 }
+
+// Float64 returns the nearest float64, for metrics and weights only.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// Round rounds half away from zero to scale decimal places.
+func (d Decimal) Round(scale int32) Decimal {
+	if d.scale <= scale {
+		return d
+	}
+	div := pow10(int64(d.scale - scale))
+	q, m := new(big.Int).QuoRem(d.c(), div, new(big.Int))
+	if new(big.Int).Mul(m.Abs(m), big.NewInt(2)).Cmp(div) >= 0 {
+		q.Add(q, big.NewInt(int64(d.Sign())))
+	}
+	return Decimal{coef: q, scale: scale}
+}
+
+// String prints every digit without trailing zeros, e.g. "0.5234".
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.c()).String()
+	if d.scale > 0 {
+		if pad := int(d.scale) + 1 - len(digits); pad > 0 {
+			digits = strings.Repeat("0", pad) + digits
+		}
+		point := len(digits) - int(d.scale)
+		digits = strings.TrimRight(digits[:point]+"."+digits[point:], "0")
+		digits = strings.TrimSuffix(digits, ".")
+	}
+	if d.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// MarshalJSON renders a JSON number with the exact digits, so clients that
+// read numbers keep working and clients that read raw text lose nothing.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a numeric string.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		*d = Decimal{}
+		return nil
+	}
+	parsed, err := ParseDecimal(strings.Trim(s, `"`))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
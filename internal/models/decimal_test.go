@@ -0,0 +1,114 @@
+package models
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDecimal(t *testing.T) {
+	cases := map[string]string{
+		"0.52340000":  "0.5234",
+		"1118250.50":  "1118250.5",
+		"-3.0":        "-3",
+		"1.5e-3":      "0.0015",
+		"2E3":         "2000",
+		"  42 ":       "42",
+		"0.000000001": "0.000000001",
+	}
+	for in, want := range cases {
+		d, err := ParseDecimal(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, d.String(), in)
+	}
+
+	for _, in := range []string{"", "abc", "1.2.3", "1-2", "1e", "."} {
+		_, err := ParseDecimal(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestParseDecimal_RejectsOutOfRange(t *testing.T) {
+	tooManyDigits := "1" + strings.Repeat("0", 64)
+	for _, in := range []string{
+		"1e-20000000",
+		"1e-2147483647",
+		"1e3000000",
+		"1e65",
+		"1e-37",
+		"0." + strings.Repeat("0", 36) + "1",
+		tooManyDigits,
+	} {
+		_, err := ParseDecimal(in)
+		assert.Error(t, err, in)
+	}
+
+	for _, in := range []string{"1e64", "1e-36", "0." + strings.Repeat("0", 35) + "1", tooManyDigits[1:]} {
+		_, err := ParseDecimal(in)
+		assert.NoError(t, err, in)
+	}
+}
+
+func TestNewDecimalFromFloat(t *testing.T) {
+	d, err := NewDecimalFromFloat(0.1)
+	require.NoError(t, err)
+	assert.Equal(t, "0.1", d.String())
+
+	for _, f := range []float64{math.NaN(), math.Inf(1), math.Inf(-1), 1e300, 1e-300} {
+		_, err := NewDecimalFromFloat(f)
+		assert.Error(t, err, "%v", f)
+		assert.True(t, DecimalFromFloat(f).IsZero(), "%v", f)
+	}
+}
+
+func TestDecimal_UnmarshalJSONRejectsHugeExponent(t *testing.T) {
+	var d Decimal
+	assert.Error(t, json.Unmarshal([]byte(`1e-2147483647`), &d))
+}
+
+func TestDecimal_ArithmeticIsExact(t *testing.T) {
+	sum := MustParseDecimal("0.1").Add(MustParseDecimal("0.2"))
+	assert.Equal(t, "0.3", sum.String(), "0.1 + 0.2 drifts in float64")
+
+	assert.Equal(t, "-0.1", MustParseDecimal("0.2").Sub(MustParseDecimal("0.3")).String())
+	assert.Equal(t, "1118212.25", MustParseDecimal("65012.34").Mul(MustParseDecimal("17.2")).Round(2).String())
+	assert.Equal(t, "100.5", MustParseDecimal("201").Quo(DecimalFromInt(2)).String())
+	assert.Equal(t, "0.333333333333333333", DecimalFromInt(1).Quo(DecimalFromInt(3)).String())
+}
+
+func TestDecimal_Round(t *testing.T) {
+	assert.Equal(t, "0.52", MustParseDecimal("0.515").Round(2).String(), "half rounds away from zero")
+	assert.Equal(t, "-0.52", MustParseDecimal("-0.515").Round(2).String())
+	assert.Equal(t, "0.51", MustParseDecimal("0.5149").Round(2).String())
+	assert.Equal(t, "65012", MustParseDecimal("65012.34").Round(0).String())
+	assert.Equal(t, "1.5", MustParseDecimal("1.5").Round(8).String(), "fewer digits are kept as they are")
+}
+
+func TestDecimal_CmpAndZero(t *testing.T) {
+	assert.Equal(t, 0, MustParseDecimal("1.50").Cmp(MustParseDecimal("1.5")))
+	assert.Equal(t, -1, MustParseDecimal("1.49").Cmp(MustParseDecimal("1.5")))
+	assert.True(t, Decimal{}.IsZero())
+	assert.True(t, MustParseDecimal("0.000").IsZero())
+	assert.Equal(t, "0", Decimal{}.String())
+}
+
+func TestDecimal_JSON(t *testing.T) {
+	data, err := json.Marshal(struct {
+		Price Decimal `json:"price"`
+		Zero  Decimal `json:"zero"`
+	}{Price: MustParseDecimal("0.52340000")})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"price":0.5234,"zero":0}`, string(data))
+
+	var decoded struct {
+		Number Decimal `json:"number"`
+		Text   Decimal `json:"text"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"number":65012.34000001,"text":"0.1"}`), &decoded))
+	assert.Equal(t, "65012.34000001", decoded.Number.String())
+	assert.Equal(t, "0.1", decoded.Text.String())
+}
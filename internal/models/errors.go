@@ -3,11 +3,34 @@ package models
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 )
 
 var ErrNoProviders = errors.New("no providers configured")
 
+// ErrVendorNotRegistered is recorded for components whose vendors have no
+// registered client, so nothing could be asked for their price.
+var ErrVendorNotRegistered = errors.New("vendor not registered")
+
+// ErrNoFXRate is returned when a currency pair cannot be converted.
+var ErrNoFXRate = errors.New("no fx rate available")
+
+// ErrUnknownAsset is returned for tickers missing from the asset registry.
+var ErrUnknownAsset = errors.New("unknown asset")
+
+// Error kinds reported by vendors. Providers wrap them in a VendorError so
+// callers can branch with errors.Is without knowing the vendor payloads.
+var (
+	ErrUnsupportedSymbol   = errors.New("symbol not supported by provider")
+	ErrUnauthorized        = errors.New("provider rejected credentials")
+	ErrRateLimited         = errors.New("provider rate limit exceeded")
+	ErrQuotaExceeded       = errors.New("provider quota or credits exhausted")
+	ErrProviderUnavailable = errors.New("provider unavailable")
+	ErrBadResponse         = errors.New("unexpected provider response")
+	ErrCircuitOpen         = errors.New("provider circuit breaker open")
+)
+
 type ProvidersError struct {
 	Ticker  string
 	Details []error
@@ -24,3 +47,49 @@ func (e ProvidersError) Error() string {
 	}
 	return b.String()
 }
+
+// VendorError is a failure reported by a single vendor.
+// Kind is one of the error kinds above and is what errors.Is matches against.
+// Cause, when set, is the underlying transport error and stays reachable
+// through errors.Is and errors.As too.
+type VendorError struct {
+	Vendor     string
+	StatusCode int
+	Code       string
+	Message    string
+	Kind       error
+	Cause      error
+}
+
+func (e VendorError) Error() string {
+	return fmt.Sprintf("%s: %s (status=%d code=%s)", e.Vendor, e.Message, e.StatusCode, e.Code)
+}
+
+func (e VendorError) Unwrap() []error {
+	if e.Cause == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Cause}
+}
+
+// SymbolErrors collects the per-symbol failures of a batch price request.
+// Symbols missing from it were priced successfully.
+type SymbolErrors map[string]error
+
+func (e SymbolErrors) Error() string {
+	symbols := make([]string, 0, len(e))
+	for symbol := range e {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%d symbols failed: ", len(e)))
+	for i, symbol := range symbols {
+		if i > 0 {
+			b.WriteString(" | ")
+		}
+		b.WriteString(symbol + ": " + e[symbol].Error())
+	}
+	return b.String()
+}
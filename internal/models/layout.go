@@ -1,11 +1,25 @@
 package models
 
+import "strings"
+
 type ComponentType string
 
 type Component struct {
 	ID        int           `json:"id"`
 	Component ComponentType `json:"component"`
 	Model     any           `json:"model"`
+	// Status is nil until the component is refreshed for the first time.
+	Status *ComponentStatus `json:"status,omitempty"`
+}
+
+// Symbol extracts the ticker from component names like "crypto_btc".
+func (c Component) Symbol() string {
+	parts := strings.Split(string(c.Component), "_")
+	symbol := "BTC"
+	if len(parts) > 1 {
+		symbol = strings.ToUpper(parts[1])
+	}
+	return symbol
 }
 
 type Layout []Component
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// AggregationStrategy names how quotes from several vendors are combined.
+type AggregationStrategy string
+
+const (
+	// AggregationNone uses the first vendor of the list only.
+	AggregationNone        AggregationStrategy = ""
+	AggregationMedian      AggregationStrategy = "median"
+	AggregationTrimmedMean AggregationStrategy = "trimmed_mean"
+	AggregationVWAP        AggregationStrategy = "vwap"
+)
+
+// ParseAggregation validates a strategy name from configuration.
+func ParseAggregation(name string) (AggregationStrategy, error) {
+	switch s := AggregationStrategy(name); s {
+	case AggregationNone, AggregationMedian, AggregationTrimmedMean, AggregationVWAP:
+		return s, nil
+	default:
+		return AggregationNone, fmt.Errorf("unknown aggregation strategy %q", name)
+	}
+}
+
+// SourcePolicy tells the poller which vendors price a component and how
+// their answers are combined.
+type SourcePolicy struct {
+	Vendors     []string
+	Aggregation AggregationStrategy
+	// Currencies are the quote currencies of the component; empty means
+	// DefaultCurrencies.
+	Currencies []string
+	// HedgeDelay starts the next vendor of a fallback chain when the current
+	// one has not answered after that long. Zero disables hedging.
+	HedgeDelay time.Duration
+	// StaleAfter flags the component price as stale once it is older than
+	// that. Zero uses the poller default.
+	StaleAfter time.Duration
+	// RefreshInterval is how often the component is polled. Zero uses the
+	// poller default.
+	RefreshInterval time.Duration
+}
+
+// QuoteCurrencies returns the currencies the component is priced in.
+func (s SourcePolicy) QuoteCurrencies() []string {
+	if len(s.Currencies) == 0 {
+		return DefaultCurrencies
+	}
+	return s.Currencies
+}
+
+// Hedged reports whether the component races its vendors instead of waiting
+// for each one in turn.
+func (s SourcePolicy) Hedged() bool {
+	return s.HedgeDelay > 0 && s.Aggregation == AggregationNone && len(s.Vendors) > 1
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// ComponentState summarizes how the last refresh of a component went.
+type ComponentState string
+
+const (
+	// StateOK means the last refresh priced the component.
+	StateOK ComponentState = "ok"
+	// StateDegraded means the last refresh failed and the last known good
+	// price is still served.
+	StateDegraded ComponentState = "degraded"
+	// StateError means the component has never been priced.
+	StateError ComponentState = "error"
+)
+
+// ComponentStatus is the refresh health of a component, rendered next to its
+// model.
+type ComponentStatus struct {
+	State               ComponentState `json:"state"`
+	LastError           string         `json:"last_error,omitempty"`
+	ConsecutiveFailures int            `json:"consecutive_failures"`
+	LastSuccess         *time.Time     `json:"last_success"`
+}
+
+// Succeeded returns the status after a refresh priced the component at at.
+func (s ComponentStatus) Succeeded(at time.Time) ComponentStatus {
+	return ComponentStatus{State: StateOK, LastSuccess: &at}
+}
+
+// Failed returns the status after a refresh failed with err. The component
+// is degraded when an earlier refresh left a price to fall back on.
+func (s ComponentStatus) Failed(err error) ComponentStatus {
+	s.State = StateError
+	if s.LastSuccess != nil {
+		s.State = StateDegraded
+	}
+	s.LastError = err.Error()
+	s.ConsecutiveFailures++
+	return s
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComponentStatus_FailedWithoutSuccessIsError(t *testing.T) {
+	status := ComponentStatus{}.Failed(errors.New("down"))
+
+	assert.Equal(t, ComponentStatus{State: StateError, LastError: "down", ConsecutiveFailures: 1}, status)
+}
+
+func TestComponentStatus_FailedAfterSuccessIsDegraded(t *testing.T) {
+	at := time.Date(2026, 10, 18, 15, 4, 5, 0, time.UTC)
+
+	status := ComponentStatus{}.Succeeded(at).Failed(errors.New("a")).Failed(errors.New("b"))
+
+	assert.Equal(t, StateDegraded, status.State)
+	assert.Equal(t, "b", status.LastError)
+	assert.Equal(t, 2, status.ConsecutiveFailures)
+	require.NotNil(t, status.LastSuccess)
+	assert.Equal(t, at, *status.LastSuccess)
+}
+
+func TestComponentStatus_SucceededResetsFailures(t *testing.T) {
+	at := time.Date(2026, 10, 18, 15, 4, 5, 0, time.UTC)
+
+	status := ComponentStatus{}.Failed(errors.New("down")).Succeeded(at)
+
+	assert.Equal(t, StateOK, status.State)
+	assert.Empty(t, status.LastError)
+	assert.Zero(t, status.ConsecutiveFailures)
+}
@@ -0,0 +1,40 @@
+package repositories
+
+import "strings"
+
+// SymbolAliases translates canonical tickers ("BTC") and ISO quote currencies
+// ("USD") into the names a vendor uses for them. Unlisted symbols pass through
+// upper-cased.
+type SymbolAliases map[string]string
+
+// Resolve returns the vendor name for a canonical symbol.
+func (a SymbolAliases) Resolve(symbol string) string {
+	symbol = strings.ToUpper(symbol)
+	if alias, ok := a[symbol]; ok {
+		return alias
+	}
+	return symbol
+}
+
+// vendorAliases is the per-vendor alias table. Vendors not listed here use
+// canonical tickers as-is.
+var vendorAliases = map[string]SymbolAliases{
+	"kraken": {
+		"BTC":  "XBT",
+		"DOGE": "XDG",
+	},
+	"binance": {
+		// Binance has no fiat USD books; USDT pairs are the closest quote.
+		"USD": "USDT",
+	},
+}
+
+// AliasesFor returns a copy of the alias table for a vendor so callers can
+// extend it without touching the shared defaults.
+func AliasesFor(vendor string) SymbolAliases {
+	aliases := SymbolAliases{}
+	for k, v := range vendorAliases[vendor] {
+		aliases[k] = v
+	}
+	return aliases
+}
@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSymbolAliases_Resolve(t *testing.T) {
+	aliases := SymbolAliases{"BTC": "XBT"}
+
+	assert.Equal(t, "XBT", aliases.Resolve("btc"))
+	assert.Equal(t, "ETH", aliases.Resolve("eth"))
+}
+
+func TestSymbolAliases_NilTablePassesThrough(t *testing.T) {
+	var aliases SymbolAliases
+	assert.Equal(t, "BTC", aliases.Resolve("btc"))
+}
+
+func TestAliasesFor_ReturnsCopy(t *testing.T) {
+	kraken := AliasesFor("kraken")
+	kraken["BTC"] = "CHANGED"
+
+	assert.Equal(t, "XBT", AliasesFor("kraken").Resolve("BTC"))
+	assert.Empty(t, AliasesFor("unknown"))
+}
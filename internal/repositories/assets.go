@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	"context"
+	"crypto-aggregator-service/internal/models"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-json"
+)
+
+// AssetSource is a vendor that lists the assets it knows.
+type AssetSource interface {
+	Name() string
+	Assets(ctx context.Context) ([]models.Asset, error)
+}
+
+// AssetRegistry holds the display metadata of every supported ticker.
+type AssetRegistry struct {
+	mu     sync.RWMutex
+	assets map[string]models.Asset // LOOKUP: Symbol -> Asset
+}
+
+// assetsFile is the on-disk format of the registry.
+type assetsFile struct {
+	Assets []struct {
+		Symbol   string `json:"symbol"`
+		Name     string `json:"name"`
+		Decimals *int32 `json:"decimals"` // models.DefaultScale when missing
+		Category string `json:"category"`
+		IconURL  string `json:"icon_url"`
+	} `json:"assets"`
+}
+
+func NewAssetRegistry(assets []models.Asset) *AssetRegistry {
+	r := &AssetRegistry{assets: make(map[string]models.Asset, len(assets))}
+	for _, asset := range assets {
+		asset.Symbol = strings.ToUpper(asset.Symbol)
+		r.assets[asset.Symbol] = asset
+	}
+	return r
+}
+
+// LoadAssetRegistry reads a JSON file like
+// {"assets":[{"symbol":"BTC","name":"Bitcoin","decimals":2}]}.
+func LoadAssetRegistry(path string) (*AssetRegistry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file assetsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid assets file %s: %w", path, err)
+	}
+
+	assets := make([]models.Asset, 0, len(file.Assets))
+	for i, entry := range file.Assets {
+		asset := models.Asset{
+			Symbol:   entry.Symbol,
+			Name:     entry.Name,
+			Decimals: models.DefaultScale,
+			Category: entry.Category,
+			IconURL:  entry.IconURL,
+		}
+		if entry.Decimals != nil {
+			asset.Decimals = *entry.Decimals
+		}
+		switch {
+		case asset.Symbol == "" || asset.Name == "":
+			return nil, fmt.Errorf("invalid assets file %s: asset %d needs a symbol and a name", path, i)
+		case asset.Decimals < 0 || asset.Decimals > 18:
+			return nil, fmt.Errorf("invalid assets file %s: decimals of %s must be between 0 and 18", path, asset.Symbol)
+		}
+		assets = append(assets, asset)
+	}
+	return NewAssetRegistry(assets), nil
+}
+
+// Lookup returns the metadata of symbol.
+func (r *AssetRegistry) Lookup(symbol string) (models.Asset, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	asset, ok := r.assets[strings.ToUpper(symbol)]
+	return asset, ok
+}
+
+// Symbols lists the registered tickers alphabetically.
+func (r *AssetRegistry) Symbols() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	symbols := make([]string, 0, len(r.assets))
+	for symbol := range r.assets {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// ValidateLayout reports every component whose ticker is not registered.
+func (r *AssetRegistry) ValidateLayout(layout models.Layout) error {
+	var problems []error
+	for _, comp := range layout {
+		if _, ok := r.Lookup(comp.Symbol()); !ok {
+			problems = append(problems, fmt.Errorf("component %d: %w %s", comp.ID, models.ErrUnknownAsset, comp.Symbol()))
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// Refresh merges the assets listed by source. Fields already set, usually by
+// the curated file, win; the vendor fills the blanks and adds new tickers.
+func (r *AssetRegistry) Refresh(ctx context.Context, source AssetSource) error {
+	assets, err := source.Assets(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, fresh := range assets {
+		symbol := strings.ToUpper(fresh.Symbol)
+		if symbol == "" {
+			continue
+		}
+		current, ok := r.assets[symbol]
+		if !ok {
+			fresh.Symbol = symbol
+			fresh.Decimals = min(max(fresh.Decimals, 0), 18)
+			r.assets[symbol] = fresh
+			continue
+		}
+		if current.Name == "" {
+			current.Name = fresh.Name
+		}
+		if current.Category == "" {
+			current.Category = fresh.Category
+		}
+		if current.IconURL == "" {
+			current.IconURL = fresh.IconURL
+		}
+		r.assets[symbol] = current
+	}
+	return nil
+}
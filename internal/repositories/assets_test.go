@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"context"
+	"crypto-aggregator-service/internal/models"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAssetSource struct {
+	assets []models.Asset
+	err    error
+}
+
+func (f fakeAssetSource) Name() string { return "fake" }
+
+func (f fakeAssetSource) Assets(ctx context.Context) ([]models.Asset, error) {
+	return f.assets, f.err
+}
+
+func TestLoadAssetRegistry(t *testing.T) {
+	assets, err := LoadAssetRegistry("testdata/assets.json")
+	require.NoError(t, err)
+
+	btc, ok := assets.Lookup("btc")
+	require.True(t, ok)
+	assert.Equal(t, models.Asset{Symbol: "BTC", Name: "Bitcoin", Decimals: 2, Category: "layer1", IconURL: "https://example.com/btc.png"}, btc)
+
+	xrp, ok := assets.Lookup("XRP")
+	require.True(t, ok)
+	assert.Equal(t, int32(models.DefaultScale), xrp.Decimals, "missing decimals use the default scale")
+
+	assert.Equal(t, []string{"BTC", "XRP"}, assets.Symbols())
+}
+
+func TestLoadAssetRegistry_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"no_name.json":  `{"assets":[{"symbol":"BTC"}]}`,
+		"decimals.json": `{"assets":[{"symbol":"BTC","name":"Bitcoin","decimals":30}]}`,
+		"syntax.json":   `{"assets":`,
+	} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+		_, err := LoadAssetRegistry(path)
+		assert.Error(t, err, name)
+	}
+
+	_, err := LoadAssetRegistry(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestAssetRegistry_ValidateLayout(t *testing.T) {
+	assets := NewAssetRegistry([]models.Asset{{Symbol: "BTC", Name: "Bitcoin"}})
+
+	assert.NoError(t, assets.ValidateLayout(models.Layout{{ID: 1, Component: "crypto_btc"}}))
+
+	err := assets.ValidateLayout(models.Layout{
+		{ID: 1, Component: "crypto_btc"},
+		{ID: 4, Component: "crypto_doge"},
+	})
+	require.ErrorIs(t, err, models.ErrUnknownAsset)
+	assert.Contains(t, err.Error(), "component 4: unknown asset DOGE")
+}
+
+func TestAssetRegistry_RefreshKeepsCuratedFields(t *testing.T) {
+	assets := NewAssetRegistry([]models.Asset{{Symbol: "BTC", Name: "Bitcoin", Decimals: 2}})
+
+	err := assets.Refresh(context.Background(), fakeAssetSource{assets: []models.Asset{
+		{Symbol: "BTC", Name: "BTC (vendor)", Decimals: 8, IconURL: "https://example.com/btc.png"},
+		{Symbol: "sol", Name: "Solana", Decimals: 9},
+	}})
+	require.NoError(t, err)
+
+	btc, _ := assets.Lookup("BTC")
+	assert.Equal(t, "Bitcoin", btc.Name)
+	assert.Equal(t, int32(2), btc.Decimals)
+	assert.Equal(t, "https://example.com/btc.png", btc.IconURL, "blank fields are filled by the vendor")
+
+	sol, ok := assets.Lookup("SOL")
+	require.True(t, ok)
+	assert.Equal(t, "Solana", sol.Name)
+}
+
+func TestAssetRegistry_RefreshFailureKeepsAssets(t *testing.T) {
+	assets := NewAssetRegistry([]models.Asset{{Symbol: "BTC", Name: "Bitcoin"}})
+
+	err := assets.Refresh(context.Background(), fakeAssetSource{err: errors.New("down")})
+
+	require.Error(t, err)
+	assert.Equal(t, []string{"BTC"}, assets.Symbols())
+}
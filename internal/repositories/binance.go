@@ -0,0 +1,218 @@
+package repositories
+
+import (
+	"context"
+	"crypto-aggregator-service/internal/models"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/goccy/go-json"
+)
+
+// BinanceProvider reads last traded prices from the Binance spot API.
+// Binance quotes in stablecoins, so "USD" is served from USDT pairs.
+type BinanceProvider struct {
+	CryptoProvider
+	Currencies []string
+	Aliases    SymbolAliases
+}
+
+func NewBinanceCryptoProvider(client *http.Client) *BinanceProvider {
+	return &BinanceProvider{
+		CryptoProvider: CryptoProvider{
+			BaseURL: "https://api.binance.com",
+			Client:  client,
+		},
+		Currencies: []string{"USD"},
+		Aliases:    AliasesFor("binance"),
+	}
+}
+
+func (c *BinanceProvider) Name() string { return "binance" }
+
+type binanceTickerResp struct {
+	Symbol string `json:"symbol"`
+	Price  string `json:"price"`
+	Code   int    `json:"code"`
+	Msg    string `json:"msg"`
+}
+
+func (c *BinanceProvider) GetPrice(ctx context.Context, symbol string) (*models.Money, error) {
+	money := &models.Money{}
+	for _, currency := range c.Currencies {
+		price, err := c.fetchPair(ctx, c.pair(symbol, currency))
+		if err != nil {
+			return nil, err
+		}
+		money.Set(currency, price)
+	}
+	return money, nil
+}
+
+// GetPrices prices all symbols with one call per configured currency. Binance
+// rejects the whole call when a single pair is unlisted; the pairs are then
+// priced one by one so the failure stays with its symbol.
+func (c *BinanceProvider) GetPrices(ctx context.Context, symbols []string) (map[string]*models.Money, error) {
+	prices := make(map[string]*models.Money, len(symbols))
+	for _, symbol := range symbols {
+		prices[symbol] = &models.Money{}
+	}
+
+	failed := models.SymbolErrors{}
+	for _, currency := range c.Currencies {
+		pairs := make([]string, len(symbols))
+		for i, symbol := range symbols {
+			pairs[i] = c.pair(symbol, currency)
+		}
+
+		quotes, err := c.fetchPairs(ctx, pairs)
+		if errors.Is(err, models.ErrUnsupportedSymbol) {
+			// One unlisted pair fails the whole multi-symbol call
+			quotes, err = c.fetchEach(ctx, symbols, pairs, failed), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for i, symbol := range symbols {
+			if _, ok := failed[symbol]; ok {
+				continue
+			}
+			price, ok := quotes[pairs[i]]
+			if !ok {
+				failed[symbol] = models.VendorError{Vendor: c.Name(), StatusCode: http.StatusOK, Message: "no ticker for " + pairs[i], Kind: models.ErrUnsupportedSymbol}
+				continue
+			}
+			prices[symbol].Set(currency, price)
+		}
+	}
+
+	for symbol := range failed {
+		delete(prices, symbol)
+	}
+	if len(failed) > 0 {
+		return prices, failed
+	}
+	return prices, nil
+}
+
+// pair builds the Binance symbol, e.g. BTC + USD -> BTCUSDT.
+func (c *BinanceProvider) pair(symbol, currency string) string {
+	return c.Aliases.Resolve(symbol) + c.Aliases.Resolve(currency)
+}
+
+func (c *BinanceProvider) fetchPair(ctx context.Context, pair string) (models.Decimal, error) {
+	endpoint := fmt.Sprintf("%s/api/v3/ticker/price?symbol=%s", c.BaseURL, url.QueryEscape(pair))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return models.Decimal{}, err
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return models.Decimal{}, transportError(c.Name(), err)
+	}
+	defer resp.Body.Close()
+
+	var result binanceTickerResp
+	decodeErr := json.NewDecoder(resp.Body).Decode(&result)
+
+	if resp.StatusCode != http.StatusOK {
+		return models.Decimal{}, c.mapError(resp.StatusCode, result)
+	}
+	if decodeErr != nil {
+		return models.Decimal{}, models.VendorError{Vendor: c.Name(), StatusCode: resp.StatusCode, Message: decodeErr.Error(), Kind: models.ErrBadResponse}
+	}
+
+	price, err := models.ParseDecimal(result.Price)
+	if err != nil {
+		return models.Decimal{}, models.VendorError{Vendor: c.Name(), StatusCode: resp.StatusCode, Message: err.Error(), Kind: models.ErrBadResponse}
+	}
+	return price, nil
+}
+
+// fetchEach prices pairs one call at a time, recording the failure of each
+// symbol in failed.
+func (c *BinanceProvider) fetchEach(ctx context.Context, symbols, pairs []string, failed models.SymbolErrors) map[string]models.Decimal {
+	quotes := make(map[string]models.Decimal, len(pairs))
+	for i, pair := range pairs {
+		if _, ok := failed[symbols[i]]; ok {
+			continue
+		}
+		price, err := c.fetchPair(ctx, pair)
+		if err != nil {
+			failed[symbols[i]] = err
+			continue
+		}
+		quotes[pair] = price
+	}
+	return quotes
+}
+
+// fetchPairs uses the multi-symbol form of the ticker endpoint.
+func (c *BinanceProvider) fetchPairs(ctx context.Context, pairs []string) (map[string]models.Decimal, error) {
+	encoded, err := json.Marshal(pairs)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("%s/api/v3/ticker/price?symbols=%s", c.BaseURL, url.QueryEscape(string(encoded)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, transportError(c.Name(), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var result binanceTickerResp
+		_ = json.NewDecoder(resp.Body).Decode(&result)
+		return nil, c.mapError(resp.StatusCode, result)
+	}
+
+	var result []binanceTickerResp
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, models.VendorError{Vendor: c.Name(), StatusCode: resp.StatusCode, Message: err.Error(), Kind: models.ErrBadResponse}
+	}
+
+	quotes := make(map[string]models.Decimal, len(result))
+	for _, ticker := range result {
+		price, err := models.ParseDecimal(ticker.Price)
+		if err != nil {
+			continue
+		}
+		quotes[ticker.Symbol] = price
+	}
+	return quotes, nil
+}
+
+func (c *BinanceProvider) mapError(status int, result binanceTickerResp) error {
+	vErr := models.VendorError{
+		Vendor:     c.Name(),
+		StatusCode: status,
+		Message:    result.Msg,
+		Kind:       kindForStatus(status),
+	}
+	if result.Code != 0 {
+		vErr.Code = strconv.Itoa(result.Code)
+	}
+	if vErr.Message == "" {
+		vErr.Message = fmt.Sprintf("binance api status %d", status)
+	}
+
+	switch {
+	case result.Code == -1121 || result.Code == -1100:
+		vErr.Kind = models.ErrUnsupportedSymbol
+	case status == http.StatusTeapot || result.Code == -1003:
+		// 418 means the IP was auto-banned after ignoring 429s.
+		vErr.Kind = models.ErrRateLimited
+	}
+	return vErr
+}
@@ -0,0 +1,170 @@
+package repositories
+
+import (
+	"context"
+	"crypto-aggregator-service/internal/models"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBinanceProvider(server *httptest.Server) *BinanceProvider {
+	p := NewBinanceCryptoProvider(server.Client())
+	p.BaseURL = server.URL
+	return p
+}
+
+func TestBinanceProvider_Name(t *testing.T) {
+	p := NewBinanceCryptoProvider(http.DefaultClient)
+	assert.Equal(t, "binance", p.Name())
+	assert.Equal(t, "https://api.binance.com", p.BaseURL)
+}
+
+func TestBinanceProvider_GetPrice_TranslatesUSDToUSDT(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v3/ticker/price", r.URL.Path)
+		assert.Equal(t, "BTCUSDT", r.URL.Query().Get("symbol"))
+		_, _ = w.Write([]byte(`{"symbol":"BTCUSDT","price":"50000.12000000"}`))
+	}))
+	defer server.Close()
+
+	p := newTestBinanceProvider(server)
+	money, err := p.GetPrice(context.Background(), "btc")
+
+	require.NoError(t, err)
+	assert.InDelta(t, 50000.12, money.USD.Float64(), 0.001)
+}
+
+func TestBinanceProvider_GetPrice_CustomAlias(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "BTCMXN", r.URL.Query().Get("symbol"))
+		_, _ = w.Write([]byte(`{"symbol":"BTCMXN","price":"850000"}`))
+	}))
+	defer server.Close()
+
+	p := newTestBinanceProvider(server)
+	p.Currencies = []string{"MXN"}
+	money, err := p.GetPrice(context.Background(), "BTC")
+
+	require.NoError(t, err)
+	assert.InDelta(t, 850000.0, money.MXN.Float64(), 0.001)
+}
+
+func TestBinanceProvider_GetPrice_MapsErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		kind   error
+	}{
+		{"invalid symbol", http.StatusBadRequest, `{"code":-1121,"msg":"Invalid symbol."}`, models.ErrUnsupportedSymbol},
+		{"rate limited", http.StatusTooManyRequests, `{"code":-1003,"msg":"Too many requests."}`, models.ErrRateLimited},
+		{"ip banned", http.StatusTeapot, `{"code":-1003,"msg":"Way too many requests."}`, models.ErrRateLimited},
+		{"server error", http.StatusBadGateway, ``, models.ErrProviderUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			p := newTestBinanceProvider(server)
+			_, err := p.GetPrice(context.Background(), "BTC")
+
+			assert.ErrorIs(t, err, tt.kind)
+		})
+	}
+}
+
+// binanceServer mimics the ticker endpoint: the multi-symbol form fails with
+// 400/-1121 as soon as one pair is unlisted.
+func binanceServer(t *testing.T, listed map[string]string, batchCalls, singleCalls *int) *httptest.Server {
+	t.Helper()
+	invalid := func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":-1121,"msg":"Invalid symbol."}`))
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if pair := r.URL.Query().Get("symbol"); pair != "" {
+			*singleCalls++
+			price, ok := listed[pair]
+			if !ok {
+				invalid(w)
+				return
+			}
+			_, _ = w.Write([]byte(`{"symbol":"` + pair + `","price":"` + price + `"}`))
+			return
+		}
+
+		*batchCalls++
+		var pairs []string
+		require.NoError(t, json.Unmarshal([]byte(r.URL.Query().Get("symbols")), &pairs))
+		var tickers []string
+		for _, pair := range pairs {
+			price, ok := listed[pair]
+			if !ok {
+				invalid(w)
+				return
+			}
+			tickers = append(tickers, `{"symbol":"`+pair+`","price":"`+price+`"}`)
+		}
+		_, _ = w.Write([]byte("[" + strings.Join(tickers, ",") + "]"))
+	}))
+}
+
+func TestBinanceProvider_GetPrices_MultiSymbolCall(t *testing.T) {
+	var batchCalls, singleCalls int
+	server := binanceServer(t, map[string]string{"BTCUSDT": "50000", "ETHUSDT": "3000"}, &batchCalls, &singleCalls)
+	defer server.Close()
+
+	p := newTestBinanceProvider(server)
+	prices, err := p.GetPrices(context.Background(), []string{"BTC", "ETH"})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, batchCalls)
+	assert.Zero(t, singleCalls)
+	assert.InDelta(t, 50000.0, prices["BTC"].USD.Float64(), 0.001)
+	assert.InDelta(t, 3000.0, prices["ETH"].USD.Float64(), 0.001)
+}
+
+func TestBinanceProvider_GetPrices_UnlistedPairFailsOnlyItsSymbol(t *testing.T) {
+	var batchCalls, singleCalls int
+	server := binanceServer(t, map[string]string{"BTCUSDT": "50000", "ETHUSDT": "3000"}, &batchCalls, &singleCalls)
+	defer server.Close()
+
+	p := newTestBinanceProvider(server)
+	prices, err := p.GetPrices(context.Background(), []string{"BTC", "ETH", "XRP"})
+
+	assert.Equal(t, 1, batchCalls)
+	assert.Equal(t, 3, singleCalls)
+	var symErrs models.SymbolErrors
+	require.ErrorAs(t, err, &symErrs)
+	assert.Len(t, symErrs, 1)
+	assert.ErrorIs(t, symErrs["XRP"], models.ErrUnsupportedSymbol)
+	assert.NotContains(t, prices, "XRP")
+	assert.InDelta(t, 50000.0, prices["BTC"].USD.Float64(), 0.001)
+	assert.InDelta(t, 3000.0, prices["ETH"].USD.Float64(), 0.001)
+}
+
+func TestBinanceProvider_GetPrices_RequestFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	p := newTestBinanceProvider(server)
+	_, err := p.GetPrices(context.Background(), []string{"BTC", "NOPE"})
+
+	var symErrs models.SymbolErrors
+	assert.False(t, errors.As(err, &symErrs), "a vendor outage fails the whole batch")
+	assert.ErrorIs(t, err, models.ErrProviderUnavailable)
+}
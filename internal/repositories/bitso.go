@@ -5,77 +5,275 @@ import (
 	"crypto-aggregator-service/internal/models"
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/goccy/go-json"
 )
 
-func NewBitsoCryptoProvider(client *http.Client) *CryptoProvider {
-	return &CryptoProvider{
-		BaseURL: "https://bitso.com",
-		Client:  client,
+// BitsoProvider serves prices from a snapshot of every Bitso book.
+// One /v3/ticker/ call returns all books, so a refresh cycle costs a single
+// request no matter how many components point at Bitso.
+type BitsoProvider struct {
+	CryptoProvider
+	// TickerTTL is how long a snapshot answers GetPrice lookups. Keep it below
+	// the shortest refresh interval so every cycle sees fresh prices; main
+	// halves that interval when the TTL would reach it.
+	TickerTTL time.Duration
+	// Currencies are the fiat books read for every symbol, e.g. MXN for btc_mxn.
+	// Empty reads every fiat book the snapshot has for the symbol.
+	Currencies []string
+
+	mu        sync.Mutex
+	books     map[string]bitsoTicker
+	fetchedAt time.Time
+}
+
+func NewBitsoCryptoProvider(client *http.Client) *BitsoProvider {
+	return &BitsoProvider{
+		CryptoProvider: CryptoProvider{
+			BaseURL: "https://api.bitso.com",
+			Client:  client,
+		},
+		TickerTTL: 2 * time.Second,
 	}
 }
 
-func (p *CryptoProvider) Name() string { return "bitso" }
+func (c *BitsoProvider) Name() string { return "bitso" }
+
+type bitsoTicker struct {
+	Book      string `json:"book"`
+	Last      string `json:"last"`
+	Volume    string `json:"volume"`
+	High      string `json:"high"`
+	Low       string `json:"low"`
+	VWAP      string `json:"vwap"`
+	Bid       string `json:"bid"`
+	Ask       string `json:"ask"`
+	Change24  string `json:"change_24"`
+	CreatedAt string `json:"created_at"`
+}
+
+// market returns the ticker statistics, leaving out fields Bitso omitted.
+func (t bitsoTicker) market() models.MarketData {
+	return models.MarketData{
+		Bid:       optionalDecimal(t.Bid),
+		Ask:       optionalDecimal(t.Ask),
+		High24h:   optionalDecimal(t.High),
+		Low24h:    optionalDecimal(t.Low),
+		Volume24h: optionalDecimal(t.Volume),
+		VWAP:      optionalDecimal(t.VWAP),
+		Change24h: optionalDecimal(t.Change24),
+	}
+}
 
-type tickerResp struct {
-	Success bool `json:"success"`
-	Payload struct {
-		Book      string `json:"book"`
-		Last      string `json:"last"`
-		CreatedAt string `json:"created_at"`
-	} `json:"payload"`
+// optionalDecimal parses a numeric string, returning nil when it is empty or
+// not a number.
+func optionalDecimal(s string) *models.Decimal {
+	v, err := models.ParseDecimal(s)
+	if err != nil {
+		return nil
+	}
+	return &v
 }
 
-func (c *CryptoProvider) GetPrice(ctx context.Context, symbol string) (*models.Money, error) {
-	// Bitso uses "btc_mxn" format
-	// Parallel fetch could be done here too, but keeping it simple for now
+type bitsoTickersResp struct {
+	Success bool          `json:"success"`
+	Payload []bitsoTicker `json:"payload"`
+	Error   struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
 
-	// Fetch MXN (Native to Bitso)
-	mxnPrice, err := c.fetchBook(ctx, strings.ToLower(symbol)+"_mxn")
+func (c *BitsoProvider) GetPrice(ctx context.Context, symbol string) (*models.Money, error) {
+	books, err := c.tickers(ctx)
 	if err != nil {
 		return nil, err
 	}
+	return c.priceFrom(books, symbol)
+}
 
-	// Fetch USD (Bitso has USD books for major coins)
-	// If it fails (some coins don't have USD pairs on Bitso), calculate it or return 0
-	usdPrice, err := c.fetchBook(ctx, strings.ToLower(symbol)+"_usd")
+// GetPrices prices every symbol from the same ticker snapshot.
+func (c *BitsoProvider) GetPrices(ctx context.Context, symbols []string) (map[string]*models.Money, error) {
+	books, err := c.tickers(ctx)
 	if err != nil {
-		// Fallback: Estimate USD based on a fixed rate or ignore
-		usdPrice = mxnPrice / 20.0 // Rough fallback for demo
+		return nil, err
+	}
+
+	prices := make(map[string]*models.Money, len(symbols))
+	failed := models.SymbolErrors{}
+	for _, symbol := range symbols {
+		money, err := c.priceFrom(books, symbol)
+		if err != nil {
+			failed[symbol] = err
+			continue
+		}
+		prices[symbol] = money
 	}
 
-	return &models.Money{
-		USD: usdPrice,
-		MXN: mxnPrice,
-	}, nil
+	if len(failed) > 0 {
+		return prices, failed
+	}
+	return prices, nil
 }
 
-func (c *CryptoProvider) fetchBook(ctx context.Context, book string) (float64, error) {
-	url := fmt.Sprintf("https://api.bitso.com/v3/ticker/?book=%s", book)
-	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+// priceFrom reads the quote currencies of symbol from the
+// "<symbol>_<currency>" books. Bitso has USD, BRL or ARS books for major coins
+// only; a missing book leaves its currency empty so the poller can convert it
+// with a real FX rate.
+func (c *BitsoProvider) priceFrom(books map[string]bitsoTicker, symbol string) (*models.Money, error) {
+	money := &models.Money{}
+	for _, currency := range c.quoteCurrencies(books, symbol) {
+		book := strings.ToLower(symbol + "_" + currency)
+		if _, ok := books[book]; !ok {
+			continue
+		}
+		price, err := c.lastPrice(books, book)
+		if err != nil {
+			return nil, err
+		}
 
-	resp, err := c.Client.Do(req)
+		money.Set(currency, price)
+		money.SetMarket(currency, books[book].market())
+		if volume, err := strconv.ParseFloat(books[book].Volume, 64); err == nil && money.Volume == 0 {
+			money.Volume = volume
+		}
+		if createdAt, err := time.Parse(time.RFC3339, books[book].CreatedAt); err == nil {
+			money.SetSourceTime(createdAt)
+		}
+	}
+
+	if len(money.Currencies()) == 0 {
+		return nil, models.VendorError{Vendor: c.Name(), StatusCode: http.StatusOK, Message: "no books for " + strings.ToLower(symbol), Kind: models.ErrUnsupportedSymbol}
+	}
+	return money, nil
+}
+
+// quoteCurrencies returns the configured currencies or, by default, every
+// fiat book of symbol in the snapshot: MXN and USD first, then the rest
+// alphabetically.
+func (c *BitsoProvider) quoteCurrencies(books map[string]bitsoTicker, symbol string) []string {
+	if len(c.Currencies) > 0 {
+		return c.Currencies
+	}
+
+	currencies := []string{"MXN", "USD"}
+	var extra []string
+	prefix := strings.ToLower(symbol) + "_"
+	for book := range books {
+		quote, ok := strings.CutPrefix(book, prefix)
+		if ok && quote != "mxn" && quote != "usd" && isBitsoFiat(books, quote) {
+			extra = append(extra, strings.ToUpper(quote))
+		}
+	}
+	sort.Strings(extra)
+	return append(currencies, extra...)
+}
+
+// isBitsoFiat reports whether quote is a fiat currency: Bitso lists a
+// usd_<fiat> book for every fiat it trades, so crypto quotes such as btc or
+// usdt are left out.
+func isBitsoFiat(books map[string]bitsoTicker, quote string) bool {
+	_, ok := books["usd_"+quote]
+	return ok && models.IsCurrencyCode(quote)
+}
+
+// tickers returns the cached snapshot, fetching a new one when it expired.
+// Concurrent callers wait on the lock and reuse the same response.
+func (c *BitsoProvider) tickers(ctx context.Context) (map[string]bitsoTicker, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.books != nil && time.Since(c.fetchedAt) < c.TickerTTL {
+		return c.books, nil
+	}
+
+	books, err := c.fetchTicker(ctx)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	c.books, c.fetchedAt = books, time.Now()
+	return books, nil
+}
+
+// Books lists every book in the ticker snapshot, sorted.
+func (c *BitsoProvider) Books(ctx context.Context) ([]string, error) {
+	books, err := c.tickers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(books))
+	for name := range books {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (c *BitsoProvider) lastPrice(books map[string]bitsoTicker, book string) (models.Decimal, error) {
+	ticker, ok := books[book]
+	if !ok {
+		return models.Decimal{}, models.VendorError{Vendor: c.Name(), StatusCode: http.StatusOK, Message: "unknown book " + book, Kind: models.ErrUnsupportedSymbol}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return 0, fmt.Errorf("bitso api status %d", resp.StatusCode)
+	price, err := models.ParseDecimal(ticker.Last)
+	if err != nil {
+		return models.Decimal{}, models.VendorError{Vendor: c.Name(), StatusCode: http.StatusOK, Message: err.Error(), Kind: models.ErrBadResponse}
+	}
+	return price, nil
+}
+
+// fetchTicker downloads every book in a single call, keyed by book name.
+func (c *BitsoProvider) fetchTicker(ctx context.Context) (map[string]bitsoTicker, error) {
+	url := fmt.Sprintf("%s/v3/ticker/", c.BaseURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
 	}
 
-	var result struct {
-		Payload struct {
-			Last string `json:"last"`
-		} `json:"payload"`
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, transportError(c.Name(), err)
 	}
+	defer resp.Body.Close()
+
+	var result bitsoTickersResp
+	decodeErr := json.NewDecoder(resp.Body).Decode(&result)
 
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return 0, err
+	if resp.StatusCode != http.StatusOK {
+		vErr := models.VendorError{
+			Vendor:     c.Name(),
+			StatusCode: resp.StatusCode,
+			Code:       result.Error.Code,
+			Message:    fmt.Sprintf("bitso api status %d", resp.StatusCode),
+			Kind:       kindForStatus(resp.StatusCode),
+		}
+		if result.Error.Message != "" {
+			vErr.Message = fmt.Sprintf("bitso api status %d: %s", resp.StatusCode, result.Error.Message)
+		}
+		return nil, vErr
+	}
+	if decodeErr != nil {
+		return nil, models.VendorError{Vendor: c.Name(), StatusCode: resp.StatusCode, Message: decodeErr.Error(), Kind: models.ErrBadResponse}
+	}
+	// Bitso can also report a failure inside a 200 response
+	if !result.Success {
+		return nil, models.VendorError{
+			Vendor:     c.Name(),
+			StatusCode: resp.StatusCode,
+			Code:       result.Error.Code,
+			Message:    "bitso api error: " + result.Error.Message,
+			Kind:       models.ErrBadResponse,
+		}
 	}
 
-	return strconv.ParseFloat(result.Payload.Last, 64)
+	books := make(map[string]bitsoTicker, len(result.Payload))
+	for _, t := range result.Payload {
+		books[t.Book] = t
+	}
+	return books, nil
 }
@@ -0,0 +1,174 @@
+package repositories
+
+import (
+	"context"
+	"crypto-aggregator-service/internal/models"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/gorilla/websocket"
+)
+
+// BookUpdate is a price change for a single Bitso book. Zero fields were not
+// part of the message.
+type BookUpdate struct {
+	Book string
+	Last models.Decimal // rate of the latest trade
+	Bid  models.Decimal // best bid
+	Ask  models.Decimal // best ask
+	Time time.Time      // vendor timestamp of the newest trade or order
+}
+
+// BitsoStream subscribes to the Bitso WebSocket trades and orders channels.
+type BitsoStream struct {
+	URL string
+	// ReadTimeout closes the connection when nothing, not even a keepalive,
+	// arrives for that long.
+	ReadTimeout time.Duration
+	Dialer      *websocket.Dialer
+}
+
+func NewBitsoStream(url string) *BitsoStream {
+	return &BitsoStream{
+		URL:         url,
+		ReadTimeout: 30 * time.Second,
+		Dialer:      websocket.DefaultDialer,
+	}
+}
+
+func (s *BitsoStream) Name() string { return "bitso" }
+
+type bitsoSubscribe struct {
+	Action string `json:"action"`
+	Book   string `json:"book"`
+	Type   string `json:"type"`
+}
+
+type bitsoStreamMessage struct {
+	Type     string          `json:"type"`
+	Book     string          `json:"book"`
+	Action   string          `json:"action"`
+	Response string          `json:"response"`
+	Payload  json.RawMessage `json:"payload"`
+}
+
+type bitsoStreamOrder struct {
+	Rate string `json:"r"`
+	Date int64  `json:"d"` // unix milliseconds
+}
+
+type bitsoStreamTrade struct {
+	Rate      string `json:"r"`
+	CreatedAt int64  `json:"x"` // unix milliseconds
+}
+
+// Stream connects, subscribes every book to trades and orders and delivers
+// updates until ctx is done or the connection drops. onReady runs once all
+// subscriptions were sent. It always returns a non-nil error.
+func (s *BitsoStream) Stream(ctx context.Context, books []string, onReady func(), onUpdate func(BookUpdate)) error {
+	conn, _, err := s.Dialer.DialContext(ctx, s.URL, nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Unblock ReadMessage when the caller cancels.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-done:
+		}
+	}()
+
+	for _, book := range books {
+		for _, channel := range []string{"trades", "orders"} {
+			if err := conn.WriteJSON(bitsoSubscribe{Action: "subscribe", Book: book, Type: channel}); err != nil {
+				return err
+			}
+		}
+	}
+	onReady()
+
+	for {
+		if s.ReadTimeout > 0 {
+			_ = conn.SetReadDeadline(time.Now().Add(s.ReadTimeout))
+		}
+
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		var msg bitsoStreamMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+		if update, ok := parseBitsoStreamMessage(msg); ok {
+			onUpdate(update)
+		}
+	}
+}
+
+// parseBitsoStreamMessage extracts prices from trades and orders messages.
+// Keepalives and subscription acks are ignored.
+func parseBitsoStreamMessage(msg bitsoStreamMessage) (BookUpdate, bool) {
+	if msg.Book == "" || len(msg.Payload) == 0 || msg.Action != "" {
+		return BookUpdate{}, false
+	}
+	update := BookUpdate{Book: msg.Book}
+
+	switch msg.Type {
+	case "trades":
+		var trades []bitsoStreamTrade
+		if err := json.Unmarshal(msg.Payload, &trades); err != nil || len(trades) == 0 {
+			return BookUpdate{}, false
+		}
+		// Trades arrive oldest first
+		update.Last = parseRate(trades[len(trades)-1].Rate)
+		update.Time = unixMilli(trades[len(trades)-1].CreatedAt)
+		return update, update.Last.Sign() > 0
+
+	case "orders":
+		var book struct {
+			Bids []bitsoStreamOrder `json:"bids"`
+			Asks []bitsoStreamOrder `json:"asks"`
+		}
+		if err := json.Unmarshal(msg.Payload, &book); err != nil {
+			return BookUpdate{}, false
+		}
+		if len(book.Bids) > 0 {
+			update.Bid = parseRate(book.Bids[0].Rate)
+			update.Time = unixMilli(book.Bids[0].Date)
+		}
+		if len(book.Asks) > 0 {
+			update.Ask = parseRate(book.Asks[0].Rate)
+			if t := unixMilli(book.Asks[0].Date); t.After(update.Time) {
+				update.Time = t
+			}
+		}
+		return update, update.Bid.Sign() > 0 || update.Ask.Sign() > 0
+	}
+	return BookUpdate{}, false
+}
+
+func parseRate(rate string) models.Decimal {
+	v, err := models.ParseDecimal(rate)
+	if err != nil {
+		return models.Decimal{}
+	}
+	return v
+}
+
+// unixMilli converts a Bitso millisecond timestamp, leaving 0 as zero time.
+func unixMilli(ms int64) time.Time {
+	if ms <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms).UTC()
+}
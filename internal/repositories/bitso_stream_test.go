@@ -0,0 +1,171 @@
+package repositories
+
+import (
+	"context"
+	"crypto-aggregator-service/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStreamServer starts a WebSocket stand-in that records subscriptions and
+// then writes messages to the client.
+func newStreamServer(t *testing.T, messages []string, subscriptions chan<- bitsoSubscribe, subscribeCount int) *httptest.Server {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for i := 0; i < subscribeCount; i++ {
+			var sub bitsoSubscribe
+			if err := conn.ReadJSON(&sub); err != nil {
+				return
+			}
+			if subscriptions != nil {
+				subscriptions <- sub
+			}
+			_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"action":"subscribe","response":"ok","time":1,"type":"`+sub.Type+`"}`))
+		}
+		for _, msg := range messages {
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+				return
+			}
+		}
+		// Hold the connection until the client leaves
+		_, _, _ = conn.ReadMessage()
+	}))
+}
+
+func wsURL(server *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func TestBitsoStream_SubscribesAndDeliversUpdates(t *testing.T) {
+	subscriptions := make(chan bitsoSubscribe, 4)
+	server := newStreamServer(t, []string{
+		`{"type":"ka"}`,
+		`{"type":"trades","book":"btc_mxn","payload":[{"i":1,"a":"0.1","r":"850000.00","v":"85000","t":0},{"i":2,"a":"0.2","r":"851000.50","v":"170200","t":1}]}`,
+		`{"type":"orders","book":"btc_usd","payload":{"bids":[{"r":"49990","a":"1","t":1}],"asks":[{"r":"50010","a":"1","t":0}]}}`,
+	}, subscriptions, 4)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		ready   bool
+		updates []BookUpdate
+	)
+	done := make(chan error, 1)
+	go func() {
+		done <- NewBitsoStream(wsURL(server)).Stream(ctx, []string{"btc_mxn", "btc_usd"},
+			func() {
+				mu.Lock()
+				ready = true
+				mu.Unlock()
+			},
+			func(u BookUpdate) {
+				mu.Lock()
+				updates = append(updates, u)
+				mu.Unlock()
+			})
+	}()
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(updates) == 2
+	}, 2*time.Second, 10*time.Millisecond)
+
+	close(subscriptions)
+	var got []bitsoSubscribe
+	for sub := range subscriptions {
+		got = append(got, sub)
+	}
+	assert.ElementsMatch(t, []bitsoSubscribe{
+		{Action: "subscribe", Book: "btc_mxn", Type: "trades"},
+		{Action: "subscribe", Book: "btc_mxn", Type: "orders"},
+		{Action: "subscribe", Book: "btc_usd", Type: "trades"},
+		{Action: "subscribe", Book: "btc_usd", Type: "orders"},
+	}, got)
+
+	mu.Lock()
+	assert.True(t, ready)
+	assert.Equal(t, BookUpdate{Book: "btc_mxn", Last: models.MustParseDecimal("851000.50")}, updates[0])
+	assert.Equal(t, BookUpdate{Book: "btc_usd", Bid: models.MustParseDecimal("49990"), Ask: models.MustParseDecimal("50010")}, updates[1])
+	mu.Unlock()
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stream did not return after cancel")
+	}
+}
+
+func TestBitsoStream_ReadTimeout(t *testing.T) {
+	server := newStreamServer(t, nil, nil, 2)
+	defer server.Close()
+
+	stream := NewBitsoStream(wsURL(server))
+	stream.ReadTimeout = 50 * time.Millisecond
+
+	err := stream.Stream(context.Background(), []string{"btc_mxn"}, func() {}, func(BookUpdate) {
+		t.Error("unexpected update")
+	})
+	require.Error(t, err)
+}
+
+func TestBitsoStream_DialError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	readyCalled := false
+	err := NewBitsoStream(wsURL(server)).Stream(context.Background(), []string{"btc_mxn"}, func() { readyCalled = true }, func(BookUpdate) {})
+
+	require.Error(t, err)
+	assert.False(t, readyCalled)
+}
+
+func TestParseBitsoStreamMessage_Ignored(t *testing.T) {
+	tests := map[string]bitsoStreamMessage{
+		"keepalive":     {Type: "ka"},
+		"subscribe ack": {Action: "subscribe", Response: "ok", Type: "trades"},
+		"empty trades":  {Type: "trades", Book: "btc_mxn", Payload: []byte(`[]`)},
+		"unknown type":  {Type: "diff-orders", Book: "btc_mxn", Payload: []byte(`[{"r":"1"}]`)},
+		"bad rate":      {Type: "trades", Book: "btc_mxn", Payload: []byte(`[{"r":"abc"}]`)},
+	}
+	for name, msg := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, ok := parseBitsoStreamMessage(msg)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestParseBitsoStreamMessage_Time(t *testing.T) {
+	trade, ok := parseBitsoStreamMessage(bitsoStreamMessage{Type: "trades", Book: "btc_mxn",
+		Payload: []byte(`[{"r":"850000","x":1792335840000},{"r":"851000","x":1792335845000}]`)})
+	require.True(t, ok)
+	assert.Equal(t, time.UnixMilli(1792335845000).UTC(), trade.Time)
+
+	orders, ok := parseBitsoStreamMessage(bitsoStreamMessage{Type: "orders", Book: "btc_usd",
+		Payload: []byte(`{"bids":[{"r":"49990","d":1792335841000}],"asks":[{"r":"50010","d":1792335843000}]}`)})
+	require.True(t, ok)
+	assert.Equal(t, time.UnixMilli(1792335843000).UTC(), orders.Time, "newest side wins")
+}
@@ -2,31 +2,24 @@ package repositories
 
 import (
 	"context"
+	"crypto-aggregator-service/internal/adapters/webclients"
+	"crypto-aggregator-service/internal/models"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
 )
 
-// redirectTransport intercepts all requests and sends them to the test server.
-type redirectTransport struct {
-	target *httptest.Server
-}
-
-func (t *redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	// Rewrite the URL to point to the test server, keeping path and query
-	req.URL.Scheme = "http"
-	req.URL.Host = t.target.Listener.Addr().String()
-	return http.DefaultTransport.RoundTrip(req)
-}
-
-func newTestProvider(server *httptest.Server) *CryptoProvider {
-	return &CryptoProvider{
-		BaseURL: server.URL,
-		Client:  &http.Client{Transport: &redirectTransport{target: server}},
-	}
+func newTestProvider(server *httptest.Server) *BitsoProvider {
+	p := NewBitsoCryptoProvider(server.Client())
+	p.BaseURL = server.URL
+	return p
 }
 
 func TestCryptoProvider_Name(t *testing.T) {
@@ -38,39 +31,88 @@ func TestNewBitsoCryptoProvider(t *testing.T) {
 	client := &http.Client{}
 	p := NewBitsoCryptoProvider(client)
 
-	assert.Equal(t, "https://bitso.com", p.BaseURL)
+	assert.Equal(t, "https://api.bitso.com", p.BaseURL)
 	assert.Same(t, client, p.Client)
+	assert.Positive(t, p.TickerTTL)
 }
 
-func TestCryptoProvider_FetchBook_Success(t *testing.T) {
+func TestCryptoProvider_FetchTicker_Success(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		assert.Equal(t, "btc_mxn", r.URL.Query().Get("book"))
+		assert.Equal(t, "/v3/ticker/", r.URL.Path)
+		assert.Empty(t, r.URL.Query().Get("book"))
 		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte(`{"payload":{"last":"850000.50"}}`))
+		_, _ = w.Write([]byte(`{"success":true,"payload":[{"book":"btc_mxn","last":"850000.50"},{"book":"eth_mxn","last":"51000.00"}]}`))
 	}))
 	defer server.Close()
 
 	p := newTestProvider(server)
-	price, err := p.fetchBook(context.Background(), "btc_mxn")
+	books, err := p.fetchTicker(context.Background())
 
 	require.NoError(t, err)
-	assert.InDelta(t, 850000.50, price, 0.01)
+	require.Len(t, books, 2)
+	assert.Equal(t, "850000.50", books["btc_mxn"].Last)
+	assert.Equal(t, "51000.00", books["eth_mxn"].Last)
 }
 
-func TestCryptoProvider_FetchBook_Non200Status(t *testing.T) {
+func TestCryptoProvider_FetchTicker_Non200Status(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusServiceUnavailable)
 	}))
 	defer server.Close()
 
 	p := newTestProvider(server)
-	_, err := p.fetchBook(context.Background(), "btc_mxn")
+	_, err := p.fetchTicker(context.Background())
 
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "bitso api status 503")
+	assert.ErrorIs(t, err, models.ErrProviderUnavailable)
+}
+
+func TestCryptoProvider_FetchTicker_ErrorPayload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"success":false,"error":{"code":"0201","message":"Too many requests"}}`))
+	}))
+	defer server.Close()
+
+	p := newTestProvider(server)
+	_, err := p.fetchTicker(context.Background())
+
+	assert.ErrorIs(t, err, models.ErrRateLimited)
+	assert.Contains(t, err.Error(), "Too many requests")
 }
 
-func TestCryptoProvider_FetchBook_InvalidJSON(t *testing.T) {
+func TestCryptoProvider_FetchTicker_UnsuccessfulOK(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"success":false,"error":{"code":"0301","message":"Unknown OrderBook"}}`))
+	}))
+	defer server.Close()
+
+	p := newTestProvider(server)
+	_, err := p.fetchTicker(context.Background())
+
+	var vErr models.VendorError
+	require.ErrorAs(t, err, &vErr)
+	assert.Equal(t, "0301", vErr.Code)
+	assert.Equal(t, http.StatusOK, vErr.StatusCode)
+	assert.Contains(t, vErr.Message, "Unknown OrderBook")
+	assert.ErrorIs(t, err, models.ErrBadResponse)
+}
+
+func TestBitsoProvider_Books(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"success":true,"payload":[{"book":"eth_mxn","last":"51000.00"},{"book":"btc_mxn","last":"850000.50"}]}`))
+	}))
+	defer server.Close()
+
+	books, err := newTestProvider(server).Books(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"btc_mxn", "eth_mxn"}, books)
+}
+
+func TestCryptoProvider_FetchTicker_InvalidJSON(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte(`{invalid json`))
@@ -78,46 +120,41 @@ func TestCryptoProvider_FetchBook_InvalidJSON(t *testing.T) {
 	defer server.Close()
 
 	p := newTestProvider(server)
-	_, err := p.fetchBook(context.Background(), "btc_mxn")
+	_, err := p.fetchTicker(context.Background())
 
 	assert.Error(t, err)
 }
 
-func TestCryptoProvider_FetchBook_InvalidPrice(t *testing.T) {
+func TestCryptoProvider_GetPrice_InvalidPrice(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte(`{"payload":{"last":"not_a_number"}}`))
+		_, _ = w.Write([]byte(`{"success":true,"payload":[{"book":"btc_mxn","last":"not_a_number"}]}`))
 	}))
 	defer server.Close()
 
 	p := newTestProvider(server)
-	_, err := p.fetchBook(context.Background(), "btc_mxn")
+	_, err := p.GetPrice(context.Background(), "BTC")
 
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, models.ErrBadResponse)
 }
 
-func TestCryptoProvider_FetchBook_ConnectionError(t *testing.T) {
+func TestCryptoProvider_FetchTicker_ConnectionError(t *testing.T) {
 	// Use a closed server to force connection error
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
 	server.Close()
 
 	p := newTestProvider(server)
-	_, err := p.fetchBook(context.Background(), "btc_mxn")
+	_, err := p.fetchTicker(context.Background())
 
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, models.ErrProviderUnavailable)
 }
 
 func TestCryptoProvider_GetPrice_BothBooksSucceed(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		book := r.URL.Query().Get("book")
-		switch book {
-		case "btc_mxn":
-			_, _ = w.Write([]byte(`{"payload":{"last":"850000.00"}}`))
-		case "btc_usd":
-			_, _ = w.Write([]byte(`{"payload":{"last":"50000.00"}}`))
-		default:
-			w.WriteHeader(http.StatusNotFound)
-		}
+		_, _ = w.Write([]byte(`{"success":true,"payload":[
+			{"book":"btc_mxn","last":"850000.00"},
+			{"book":"btc_usd","last":"50000.00"}
+		]}`))
 	}))
 	defer server.Close()
 
@@ -125,22 +162,14 @@ func TestCryptoProvider_GetPrice_BothBooksSucceed(t *testing.T) {
 	money, err := p.GetPrice(context.Background(), "BTC")
 
 	require.NoError(t, err)
-	assert.InDelta(t, 50000.00, money.USD, 0.01)
-	assert.InDelta(t, 850000.00, money.MXN, 0.01)
+	assert.InDelta(t, 50000.00, money.USD.Float64(), 0.01)
+	assert.InDelta(t, 850000.00, money.MXN.Float64(), 0.01)
 }
 
-func TestCryptoProvider_GetPrice_USDFallback(t *testing.T) {
-	// USD book returns error, should fallback to MXN/20
+func TestCryptoProvider_GetPrice_NoUSDBookLeavesUSDEmpty(t *testing.T) {
+	// No USD book in the ticker: USD is left for FX conversion, never guessed
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		book := r.URL.Query().Get("book")
-		switch book {
-		case "btc_mxn":
-			_, _ = w.Write([]byte(`{"payload":{"last":"850000.00"}}`))
-		case "btc_usd":
-			w.WriteHeader(http.StatusNotFound)
-		default:
-			w.WriteHeader(http.StatusNotFound)
-		}
+		_, _ = w.Write([]byte(`{"success":true,"payload":[{"book":"btc_mxn","last":"850000.00"}]}`))
 	}))
 	defer server.Close()
 
@@ -148,13 +177,66 @@ func TestCryptoProvider_GetPrice_USDFallback(t *testing.T) {
 	money, err := p.GetPrice(context.Background(), "BTC")
 
 	require.NoError(t, err)
-	assert.InDelta(t, 850000.00, money.MXN, 0.01)
-	// USD should be MXN / 20 as fallback
-	assert.InDelta(t, 42500.00, money.USD, 0.01)
+	assert.InDelta(t, 850000.00, money.MXN.Float64(), 0.01)
+	assert.Zero(t, money.USD.Float64())
+	assert.Empty(t, money.Derived)
+}
+
+func TestCryptoProvider_GetPrice_ConfiguredCurrencies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"success":true,"payload":[
+			{"book":"btc_mxn","last":"850000.00"},
+			{"book":"btc_brl","last":"250000.00"},
+			{"book":"btc_ars","last":"45000000.00"}
+		]}`))
+	}))
+	defer server.Close()
+
+	p := newTestProvider(server)
+	p.Currencies = []string{"BRL", "ARS", "COP"}
+	money, err := p.GetPrice(context.Background(), "BTC")
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ARS", "BRL"}, money.Currencies(), "no btc_cop book, MXN not requested")
+	assert.InDelta(t, 250000.0, money.Quotes["BRL"].Float64(), 0.01)
+}
+
+func TestCryptoProvider_GetPrice_ReadsEveryFiatBookByDefault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"success":true,"payload":[
+			{"book":"xrp_mxn","last":"8.94"},
+			{"book":"xrp_brl","last":"2.91"},
+			{"book":"xrp_usdt","last":"0.52"},
+			{"book":"xrp_btc","last":"0.000008"},
+			{"book":"usd_mxn","last":"17.20"},
+			{"book":"usd_brl","last":"5.60"}
+		]}`))
+	}))
+	defer server.Close()
+
+	p := newTestProvider(server)
+	money, err := p.GetPrice(context.Background(), "XRP")
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"MXN", "BRL"}, money.Currencies(), "crypto quotes are not fiat")
+	assert.InDelta(t, 2.91, money.Quotes["BRL"].Float64(), 0.001)
+	assert.Empty(t, money.Derived, "BRL is a native book")
+}
+
+func TestCryptoProvider_GetPrice_MXNBookMissing_ReturnsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"success":true,"payload":[{"book":"eth_mxn","last":"51000.00"}]}`))
+	}))
+	defer server.Close()
+
+	p := newTestProvider(server)
+	_, err := p.GetPrice(context.Background(), "BTC")
+
+	assert.ErrorIs(t, err, models.ErrUnsupportedSymbol)
 }
 
 func TestCryptoProvider_GetPrice_MXNFails_ReturnsError(t *testing.T) {
-	// If the MXN book fails, GetPrice should return an error
+	// If the ticker call fails, GetPrice should return an error
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
 	}))
@@ -167,17 +249,168 @@ func TestCryptoProvider_GetPrice_MXNFails_ReturnsError(t *testing.T) {
 }
 
 func TestCryptoProvider_GetPrice_LowercasesSymbol(t *testing.T) {
-	var receivedBooks []string
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		receivedBooks = append(receivedBooks, r.URL.Query().Get("book"))
-		_, _ = w.Write([]byte(`{"payload":{"last":"100.00"}}`))
+		_, _ = w.Write([]byte(`{"success":true,"payload":[{"book":"eth_mxn","last":"100.00"},{"book":"eth_usd","last":"5.00"}]}`))
 	}))
 	defer server.Close()
 
 	p := newTestProvider(server)
-	_, err := p.GetPrice(context.Background(), "ETH")
+	money, err := p.GetPrice(context.Background(), "ETH")
 
 	require.NoError(t, err)
-	assert.Contains(t, receivedBooks, "eth_mxn")
-	assert.Contains(t, receivedBooks, "eth_usd")
+	assert.InDelta(t, 100.00, money.MXN.Float64(), 0.01)
+	assert.InDelta(t, 5.00, money.USD.Float64(), 0.01)
+}
+
+func TestCryptoProvider_GetPrice_SingleTickerCallPerRefresh(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_, _ = w.Write([]byte(`{"success":true,"payload":[
+			{"book":"btc_mxn","last":"850000"},{"book":"btc_usd","last":"50000"},
+			{"book":"eth_mxn","last":"51000"},{"book":"eth_usd","last":"3000"},
+			{"book":"xrp_mxn","last":"10"},{"book":"xrp_usd","last":"0.5"}
+		]}`))
+	}))
+	defer server.Close()
+
+	p := newTestProvider(server)
+
+	var wg sync.WaitGroup
+	for _, symbol := range []string{"BTC", "ETH", "XRP"} {
+		wg.Add(1)
+		go func(s string) {
+			defer wg.Done()
+			_, err := p.GetPrice(context.Background(), s)
+			assert.NoError(t, err)
+		}(symbol)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestCryptoProvider_GetPrice_RefetchesAfterTTL(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_, _ = w.Write([]byte(`{"success":true,"payload":[{"book":"btc_mxn","last":"850000"},{"book":"btc_usd","last":"50000"}]}`))
+	}))
+	defer server.Close()
+
+	p := newTestProvider(server)
+	p.TickerTTL = time.Millisecond
+
+	_, err := p.GetPrice(context.Background(), "BTC")
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+	_, err = p.GetPrice(context.Background(), "BTC")
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestCryptoProvider_GetPrice_FailedFetchIsNotCached(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{"success":true,"payload":[{"book":"btc_mxn","last":"850000"},{"book":"btc_usd","last":"50000"}]}`))
+	}))
+	defer server.Close()
+
+	p := newTestProvider(server)
+
+	_, err := p.GetPrice(context.Background(), "BTC")
+	require.Error(t, err)
+	money, err := p.GetPrice(context.Background(), "BTC")
+	require.NoError(t, err)
+
+	assert.InDelta(t, 50000.0, money.USD.Float64(), 0.01)
+}
+
+func TestCryptoProvider_GetPrices_PartialFailure(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_, _ = w.Write([]byte(`{"success":true,"payload":[{"book":"btc_mxn","last":"850000"},{"book":"btc_usd","last":"50000"}]}`))
+	}))
+	defer server.Close()
+
+	p := newTestProvider(server)
+	prices, err := p.GetPrices(context.Background(), []string{"BTC", "DOGE"})
+
+	var symErrs models.SymbolErrors
+	require.ErrorAs(t, err, &symErrs)
+	assert.ErrorIs(t, symErrs["DOGE"], models.ErrUnsupportedSymbol)
+	assert.NotContains(t, symErrs, "BTC")
+	assert.InDelta(t, 50000.0, prices["BTC"].USD.Float64(), 0.01)
+	assert.NotContains(t, prices, "DOGE")
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestCryptoProvider_GetPrice_ReportsVolume(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"success":true,"payload":[{"book":"btc_mxn","last":"850000","volume":"12.5"}]}`))
+	}))
+	defer server.Close()
+
+	p := newTestProvider(server)
+	money, err := p.GetPrice(context.Background(), "BTC")
+
+	require.NoError(t, err)
+	assert.InDelta(t, 12.5, money.Volume, 0.001)
+}
+
+func TestCryptoProvider_GetPrice_ReportsMarketData(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"success":true,"payload":[
+			{"book":"btc_mxn","last":"850000","bid":"849000","ask":"851000","high":"860000","low":"840000","volume":"12.5","vwap":"852000","change_24":"-1500"},
+			{"book":"btc_usd","last":"50000","bid":"49990"}]}`))
+	}))
+	defer server.Close()
+
+	p := newTestProvider(server)
+	money, err := p.GetPrice(context.Background(), "BTC")
+
+	require.NoError(t, err)
+	mxn := money.Market["mxn"]
+	require.NotNil(t, mxn.Spread)
+	assert.InDelta(t, 2000.0, mxn.Spread.Float64(), 0.001)
+	assert.InDelta(t, 860000.0, mxn.High24h.Float64(), 0.001)
+	assert.InDelta(t, 840000.0, mxn.Low24h.Float64(), 0.001)
+	assert.InDelta(t, 12.5, mxn.Volume24h.Float64(), 0.001)
+	assert.InDelta(t, 852000.0, mxn.VWAP.Float64(), 0.001)
+	assert.InDelta(t, -1500.0, mxn.Change24h.Float64(), 0.001)
+
+	// Fields missing from the book stay nil
+	usd := money.Market["usd"]
+	assert.InDelta(t, 49990.0, usd.Bid.Float64(), 0.001)
+	assert.Nil(t, usd.Ask)
+	assert.Nil(t, usd.Spread)
+	assert.Nil(t, usd.High24h)
+}
+
+func TestBitsoProvider_ClientSideRateLimitKeepsKind(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"success":true,"payload":[{"book":"btc_mxn","last":"850000"}]}`))
+	}))
+	defer server.Close()
+
+	client := webclients.NewClient(time.Second, webclients.WithRateLimit("bitso", rate.NewLimiter(rate.Every(time.Minute), 1)))
+	p := NewBitsoCryptoProvider(client)
+	p.BaseURL = server.URL
+	p.TickerTTL = 0
+
+	_, err := p.GetPrice(context.Background(), "BTC")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = p.GetPrice(ctx, "BTC")
+
+	assert.ErrorIs(t, err, models.ErrRateLimited)
+	assert.NotErrorIs(t, err, models.ErrProviderUnavailable)
 }
@@ -0,0 +1,231 @@
+package repositories
+
+import (
+	"context"
+	"crypto-aggregator-service/internal/adapters/webclients"
+	"crypto-aggregator-service/internal/models"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	breakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "vendor_circuit_state",
+		Help: "Circuit breaker state per vendor: 0 closed, 1 half-open, 2 open.",
+	}, []string{"vendor"})
+	breakerTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "vendor_circuit_transitions_total",
+		Help: "Circuit breaker state changes per vendor.",
+	}, []string{"vendor", "state"})
+)
+
+// BreakerState is the state of a CircuitBreaker.
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerHalfOpen
+	BreakerOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerHalfOpen:
+		return "half-open"
+	case BreakerOpen:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+// BreakerConfig tunes a CircuitBreaker; zero values take the defaults.
+type BreakerConfig struct {
+	FailureThreshold int           // consecutive failures that open the circuit (5)
+	OpenTimeout      time.Duration // time open before probing again (30s)
+	HalfOpenProbes   int           // calls allowed while half-open (1)
+}
+
+// BreakerStatus is a point-in-time view of a CircuitBreaker.
+type BreakerStatus struct {
+	Vendor              string    `json:"vendor"`
+	State               string    `json:"state"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	OpenedAt            time.Time `json:"opened_at,omitempty"`
+}
+
+// CircuitBreaker stops calling a vendor after repeated failures. While open
+// every call fails immediately with models.ErrCircuitOpen; after OpenTimeout
+// a few probe calls decide whether it closes again.
+type CircuitBreaker struct {
+	vendor string
+	cfg    BreakerConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probes   int
+}
+
+func NewCircuitBreaker(vendor string, cfg BreakerConfig) *CircuitBreaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 5
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = 30 * time.Second
+	}
+	if cfg.HalfOpenProbes <= 0 {
+		cfg.HalfOpenProbes = 1
+	}
+	b := &CircuitBreaker{vendor: vendor, cfg: cfg, now: time.Now}
+	breakerState.WithLabelValues(vendor).Set(float64(BreakerClosed))
+	return b
+}
+
+// Allow reports whether a call may go through, reserving a probe slot when
+// the breaker is half-open.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= b.cfg.OpenTimeout {
+		b.transition(BreakerHalfOpen)
+	}
+
+	switch b.state {
+	case BreakerOpen:
+		return models.VendorError{Vendor: b.vendor, Message: "circuit breaker open", Kind: models.ErrCircuitOpen}
+	case BreakerHalfOpen:
+		if b.probes >= b.cfg.HalfOpenProbes {
+			return models.VendorError{Vendor: b.vendor, Message: "circuit breaker half-open, probe in flight", Kind: models.ErrCircuitOpen}
+		}
+		b.probes++
+	}
+	return nil
+}
+
+// Record feeds the outcome of an allowed call back into the breaker.
+func (b *CircuitBreaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !isVendorFailure(err) {
+		b.failures = 0
+		if b.state != BreakerClosed {
+			b.transition(BreakerClosed)
+		}
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.cfg.FailureThreshold {
+		b.openedAt = b.now()
+		b.transition(BreakerOpen)
+	}
+}
+
+// record is Record for calls made with ctx. A call its caller cancelled,
+// like the loser of a hedged request, says nothing about the vendor: it only
+// gives back its half-open probe slot. Vendors wrap transport errors, so the
+// cancellation is read from ctx rather than from err.
+func (b *CircuitBreaker) record(ctx context.Context, err error) {
+	if err == nil || !errors.Is(ctx.Err(), context.Canceled) {
+		b.Record(err)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+}
+
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *CircuitBreaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{Vendor: b.vendor, State: b.state.String(), ConsecutiveFailures: b.failures}
+	if b.state != BreakerClosed {
+		status.OpenedAt = b.openedAt
+	}
+	return status
+}
+
+// transition changes state and updates the metrics. Callers hold b.mu.
+func (b *CircuitBreaker) transition(to BreakerState) {
+	if b.state == to {
+		return
+	}
+	b.state, b.probes = to, 0
+	breakerState.WithLabelValues(b.vendor).Set(float64(to))
+	breakerTransitions.WithLabelValues(b.vendor, to.String()).Inc()
+}
+
+// isVendorFailure tells vendor-wide failures from answers that only concern
+// some symbols, a caller that gave up or our own rate limiter holding the
+// request back, which says nothing about the vendor.
+func isVendorFailure(err error) bool {
+	var (
+		symErrs   models.SymbolErrors
+		throttled *webclients.RateLimitError
+	)
+	switch {
+	case err == nil,
+		errors.As(err, &symErrs),
+		errors.Is(err, models.ErrUnsupportedSymbol),
+		errors.Is(err, context.Canceled),
+		errors.As(err, &throttled):
+		return false
+	}
+	return true
+}
+
+// WithCircuitBreaker guards client with b. Batch clients stay batch clients.
+func WithCircuitBreaker(client CryptoClient, b *CircuitBreaker) CryptoClient {
+	guarded := &breakerClient{CryptoClient: client, breaker: b}
+	if batch, ok := client.(BatchCryptoClient); ok {
+		return &breakerBatchClient{breakerClient: guarded, batch: batch}
+	}
+	return guarded
+}
+
+type breakerClient struct {
+	CryptoClient
+	breaker *CircuitBreaker
+}
+
+func (c *breakerClient) GetPrice(ctx context.Context, symbol string) (*models.Money, error) {
+	if err := c.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	price, err := c.CryptoClient.GetPrice(ctx, symbol)
+	c.breaker.record(ctx, err)
+	return price, err
+}
+
+type breakerBatchClient struct {
+	*breakerClient
+	batch BatchCryptoClient
+}
+
+func (c *breakerBatchClient) GetPrices(ctx context.Context, symbols []string) (map[string]*models.Money, error) {
+	if err := c.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	prices, err := c.batch.GetPrices(ctx, symbols)
+	c.breaker.record(ctx, err)
+	return prices, err
+}
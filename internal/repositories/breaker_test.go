@@ -0,0 +1,158 @@
+package repositories
+
+import (
+	"context"
+	"crypto-aggregator-service/internal/adapters/webclients"
+	"crypto-aggregator-service/internal/models"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errVendorDown = models.VendorError{Vendor: "test", Message: "down", Kind: models.ErrProviderUnavailable}
+
+func newTestBreaker(cfg BreakerConfig) (*CircuitBreaker, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := NewCircuitBreaker("test", cfg)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func TestCircuitBreaker_OpensAfterThreshold(t *testing.T) {
+	b, _ := newTestBreaker(BreakerConfig{FailureThreshold: 3})
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, b.Allow())
+		b.Record(errVendorDown)
+	}
+	assert.Equal(t, BreakerClosed, b.State())
+
+	require.NoError(t, b.Allow())
+	b.Record(errVendorDown)
+	assert.Equal(t, BreakerOpen, b.State())
+
+	err := b.Allow()
+	assert.ErrorIs(t, err, models.ErrCircuitOpen)
+	assert.Equal(t, "open", b.Status().State)
+	assert.Equal(t, 3, b.Status().ConsecutiveFailures)
+}
+
+func TestCircuitBreaker_SuccessResetsFailures(t *testing.T) {
+	b, _ := newTestBreaker(BreakerConfig{FailureThreshold: 2})
+
+	b.Record(errVendorDown)
+	b.Record(nil)
+	b.Record(errVendorDown)
+
+	assert.Equal(t, BreakerClosed, b.State())
+	assert.Equal(t, 1, b.Status().ConsecutiveFailures)
+}
+
+func TestCircuitBreaker_HalfOpenProbe(t *testing.T) {
+	b, now := newTestBreaker(BreakerConfig{FailureThreshold: 1, OpenTimeout: 10 * time.Second})
+	b.Record(errVendorDown)
+	require.Equal(t, BreakerOpen, b.State())
+
+	*now = now.Add(9 * time.Second)
+	assert.ErrorIs(t, b.Allow(), models.ErrCircuitOpen)
+
+	*now = now.Add(time.Second)
+	require.NoError(t, b.Allow())
+	assert.Equal(t, BreakerHalfOpen, b.State())
+	// Only one probe at a time
+	assert.ErrorIs(t, b.Allow(), models.ErrCircuitOpen)
+
+	// A failed probe opens the circuit again for a full timeout
+	b.Record(errVendorDown)
+	assert.Equal(t, BreakerOpen, b.State())
+	assert.ErrorIs(t, b.Allow(), models.ErrCircuitOpen)
+
+	*now = now.Add(10 * time.Second)
+	require.NoError(t, b.Allow())
+	b.Record(nil)
+	assert.Equal(t, BreakerClosed, b.State())
+	assert.NoError(t, b.Allow())
+	assert.True(t, b.Status().OpenedAt.IsZero())
+}
+
+func TestCircuitBreaker_IgnoresSymbolLevelFailures(t *testing.T) {
+	b, _ := newTestBreaker(BreakerConfig{FailureThreshold: 1})
+
+	b.Record(models.VendorError{Vendor: "test", Kind: models.ErrUnsupportedSymbol})
+	b.Record(models.SymbolErrors{"BTC": errVendorDown})
+	b.Record(context.Canceled)
+	assert.Equal(t, BreakerClosed, b.State())
+
+	b.Record(context.DeadlineExceeded)
+	assert.Equal(t, BreakerOpen, b.State())
+}
+
+func TestCircuitBreaker_IgnoresClientSideThrottling(t *testing.T) {
+	b, _ := newTestBreaker(BreakerConfig{FailureThreshold: 1})
+
+	throttled := transportError("test", &url.Error{Op: "Get", URL: "https://vendor", Err: &webclients.RateLimitError{Vendor: "test"}})
+	require.ErrorIs(t, throttled, models.ErrRateLimited)
+	b.Record(throttled)
+	assert.Equal(t, BreakerClosed, b.State(), "our own token bucket says nothing about the vendor")
+
+	b.Record(models.VendorError{Vendor: "test", StatusCode: http.StatusTooManyRequests, Kind: models.ErrRateLimited})
+	assert.Equal(t, BreakerOpen, b.State(), "a 429 from the vendor still counts")
+}
+
+func TestWithCircuitBreaker_CancelledCallIsNotAFailure(t *testing.T) {
+	stub := &stubBatchClient{err: errors.New("request aborted")}
+	b, now := newTestBreaker(BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Second})
+	b.Record(errVendorDown)
+	*now = now.Add(time.Second)
+	client := WithCircuitBreaker(stub, b)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := client.GetPrice(ctx, "BTC")
+	require.Error(t, err)
+
+	// The probe slot is handed back and the circuit stays half-open
+	assert.Equal(t, BreakerHalfOpen, b.State())
+	assert.NoError(t, b.Allow())
+}
+
+type stubBatchClient struct {
+	calls int
+	err   error
+}
+
+func (s *stubBatchClient) Name() string { return "stub" }
+
+func (s *stubBatchClient) GetPrice(ctx context.Context, symbol string) (*models.Money, error) {
+	s.calls++
+	return nil, s.err
+}
+
+func (s *stubBatchClient) GetPrices(ctx context.Context, symbols []string) (map[string]*models.Money, error) {
+	s.calls++
+	return nil, s.err
+}
+
+func TestWithCircuitBreaker_PreservesBatchAndShortCircuits(t *testing.T) {
+	stub := &stubBatchClient{err: errors.New("boom")}
+	b, _ := newTestBreaker(BreakerConfig{FailureThreshold: 2})
+
+	client := WithCircuitBreaker(stub, b)
+	batch, ok := client.(BatchCryptoClient)
+	require.True(t, ok)
+	assert.Equal(t, "stub", client.Name())
+
+	_, err := batch.GetPrices(context.Background(), []string{"BTC"})
+	assert.EqualError(t, err, "boom")
+	_, err = client.GetPrice(context.Background(), "BTC")
+	assert.EqualError(t, err, "boom")
+
+	_, err = batch.GetPrices(context.Background(), []string{"BTC"})
+	assert.ErrorIs(t, err, models.ErrCircuitOpen)
+	assert.Equal(t, 2, stub.calls)
+}
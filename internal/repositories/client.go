@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"crypto-aggregator-service/internal/models"
+	"errors"
 	"net/http"
 )
 
@@ -16,3 +17,49 @@ type CryptoClient interface {
 	GetPrice(ctx context.Context, symbol string) (*models.Money, error)
 	Name() string
 }
+
+// BatchCryptoClient is implemented by vendors that can price several symbols
+// with a single request. When only some symbols fail, the returned error is a
+// models.SymbolErrors and the map holds the symbols that succeeded.
+type BatchCryptoClient interface {
+	CryptoClient
+	GetPrices(ctx context.Context, symbols []string) (map[string]*models.Money, error)
+}
+
+// BookStream pushes live book updates from a vendor. Stream blocks until the
+// connection drops or ctx is done, calling onReady once it is subscribed.
+type BookStream interface {
+	Name() string
+	Stream(ctx context.Context, books []string, onReady func(), onUpdate func(BookUpdate)) error
+}
+
+// BookLister lists the books a vendor trades, e.g. btc_mxn.
+type BookLister interface {
+	Books(ctx context.Context) ([]string, error)
+}
+
+// transportError wraps a failed round trip. Client-side throttling keeps its
+// rate limit kind so it is not mistaken for an unreachable vendor.
+func transportError(vendor string, err error) error {
+	kind := models.ErrProviderUnavailable
+	if errors.Is(err, models.ErrRateLimited) {
+		kind = models.ErrRateLimited
+	}
+	return models.VendorError{Vendor: vendor, Message: err.Error(), Kind: kind, Cause: err}
+}
+
+// kindForStatus maps an HTTP status to one of the models error kinds.
+func kindForStatus(status int) error {
+	switch {
+	case status == http.StatusNotFound || status == http.StatusBadRequest:
+		return models.ErrUnsupportedSymbol
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return models.ErrUnauthorized
+	case status == http.StatusTooManyRequests:
+		return models.ErrRateLimited
+	case status >= http.StatusInternalServerError:
+		return models.ErrProviderUnavailable
+	default:
+		return models.ErrBadResponse
+	}
+}
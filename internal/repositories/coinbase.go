@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"context"
+	"crypto-aggregator-service/internal/models"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// CoinbaseProvider reads spot prices from the Coinbase public API.
+type CoinbaseProvider struct {
+	CryptoProvider
+	// Currencies are the fiat quotes requested for every symbol. USD must be present.
+	Currencies []string
+}
+
+func NewCoinbaseCryptoProvider(client *http.Client) *CoinbaseProvider {
+	return &CoinbaseProvider{
+		CryptoProvider: CryptoProvider{
+			BaseURL: "https://api.coinbase.com",
+			Client:  client,
+		},
+		Currencies: []string{"USD", "MXN"},
+	}
+}
+
+func (c *CoinbaseProvider) Name() string { return "coinbase" }
+
+type coinbaseSpotResp struct {
+	Data struct {
+		Base     string `json:"base"`
+		Currency string `json:"currency"`
+		Amount   string `json:"amount"`
+	} `json:"data"`
+	Errors []struct {
+		ID      string `json:"id"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func (c *CoinbaseProvider) GetPrice(ctx context.Context, symbol string) (*models.Money, error) {
+	money := &models.Money{}
+	for _, currency := range c.Currencies {
+		price, err := c.fetchSpot(ctx, strings.ToUpper(symbol)+"-"+strings.ToUpper(currency))
+		if err != nil {
+			return nil, err
+		}
+		money.Set(currency, price)
+	}
+	return money, nil
+}
+
+func (c *CoinbaseProvider) fetchSpot(ctx context.Context, pair string) (models.Decimal, error) {
+	url := fmt.Sprintf("%s/v2/prices/%s/spot", c.BaseURL, pair)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return models.Decimal{}, err
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return models.Decimal{}, transportError(c.Name(), err)
+	}
+	defer resp.Body.Close()
+
+	var result coinbaseSpotResp
+	decodeErr := json.NewDecoder(resp.Body).Decode(&result)
+
+	if resp.StatusCode != http.StatusOK {
+		return models.Decimal{}, c.mapError(resp.StatusCode, result)
+	}
+	if decodeErr != nil {
+		return models.Decimal{}, models.VendorError{Vendor: c.Name(), StatusCode: resp.StatusCode, Message: decodeErr.Error(), Kind: models.ErrBadResponse}
+	}
+
+	price, err := models.ParseDecimal(result.Data.Amount)
+	if err != nil {
+		return models.Decimal{}, models.VendorError{Vendor: c.Name(), StatusCode: resp.StatusCode, Message: err.Error(), Kind: models.ErrBadResponse}
+	}
+	return price, nil
+}
+
+type coinbaseCurrenciesResp struct {
+	Data []struct {
+		Code     string `json:"code"`
+		Name     string `json:"name"`
+		Exponent int32  `json:"exponent"`
+	} `json:"data"`
+	Errors []struct {
+		ID      string `json:"id"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// Assets lists the crypto currencies Coinbase supports, making it an
+// AssetSource. Coinbase has no categories or icons; the exponent becomes the
+// decimals of assets the registry did not know.
+func (c *CoinbaseProvider) Assets(ctx context.Context) ([]models.Asset, error) {
+	url := fmt.Sprintf("%s/v2/currencies/crypto", c.BaseURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, transportError(c.Name(), err)
+	}
+	defer resp.Body.Close()
+
+	var result coinbaseCurrenciesResp
+	decodeErr := json.NewDecoder(resp.Body).Decode(&result)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.mapError(resp.StatusCode, coinbaseSpotResp{Errors: result.Errors})
+	}
+	if decodeErr != nil {
+		return nil, models.VendorError{Vendor: c.Name(), StatusCode: resp.StatusCode, Message: decodeErr.Error(), Kind: models.ErrBadResponse}
+	}
+
+	assets := make([]models.Asset, 0, len(result.Data))
+	for _, currency := range result.Data {
+		assets = append(assets, models.Asset{Symbol: currency.Code, Name: currency.Name, Decimals: currency.Exponent})
+	}
+	return assets, nil
+}
+
+// mapError converts a Coinbase error payload into a typed VendorError.
+func (c *CoinbaseProvider) mapError(status int, result coinbaseSpotResp) error {
+	vErr := models.VendorError{
+		Vendor:     c.Name(),
+		StatusCode: status,
+		Message:    fmt.Sprintf("coinbase api status %d", status),
+		Kind:       kindForStatus(status),
+	}
+	if len(result.Errors) > 0 {
+		vErr.Code = result.Errors[0].ID
+		vErr.Message = result.Errors[0].Message
+	}
+
+	switch vErr.Code {
+	case "not_found", "invalid_request":
+		vErr.Kind = models.ErrUnsupportedSymbol
+	case "rate_limit_exceeded":
+		vErr.Kind = models.ErrRateLimited
+	case "authentication_error", "invalid_token", "expired_token", "revoked_token":
+		vErr.Kind = models.ErrUnauthorized
+	}
+	return vErr
+}
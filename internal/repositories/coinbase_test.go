@@ -0,0 +1,162 @@
+package repositories
+
+import (
+	"context"
+	"crypto-aggregator-service/internal/models"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCoinbaseProvider(server *httptest.Server) *CoinbaseProvider {
+	p := NewCoinbaseCryptoProvider(server.Client())
+	p.BaseURL = server.URL
+	return p
+}
+
+func TestCoinbaseProvider_Name(t *testing.T) {
+	p := NewCoinbaseCryptoProvider(http.DefaultClient)
+	assert.Equal(t, "coinbase", p.Name())
+}
+
+func TestNewCoinbaseCryptoProvider(t *testing.T) {
+	client := &http.Client{}
+	p := NewCoinbaseCryptoProvider(client)
+
+	assert.Equal(t, "https://api.coinbase.com", p.BaseURL)
+	assert.Same(t, client, p.Client)
+	assert.Equal(t, []string{"USD", "MXN"}, p.Currencies)
+}
+
+func TestCoinbaseProvider_GetPrice_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/prices/BTC-USD/spot":
+			_, _ = w.Write([]byte(`{"data":{"base":"BTC","currency":"USD","amount":"50000.10"}}`))
+		case "/v2/prices/BTC-MXN/spot":
+			_, _ = w.Write([]byte(`{"data":{"base":"BTC","currency":"MXN","amount":"850000.20"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p := newTestCoinbaseProvider(server)
+	money, err := p.GetPrice(context.Background(), "btc")
+
+	require.NoError(t, err)
+	assert.InDelta(t, 50000.10, money.USD.Float64(), 0.01)
+	assert.InDelta(t, 850000.20, money.MXN.Float64(), 0.01)
+}
+
+func TestCoinbaseProvider_GetPrice_OnlyConfiguredCurrencies(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		_, _ = w.Write([]byte(`{"data":{"amount":"3000.00"}}`))
+	}))
+	defer server.Close()
+
+	p := newTestCoinbaseProvider(server)
+	p.Currencies = []string{"USD"}
+	money, err := p.GetPrice(context.Background(), "ETH")
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/v2/prices/ETH-USD/spot"}, paths)
+	assert.InDelta(t, 3000.00, money.USD.Float64(), 0.01)
+	assert.Zero(t, money.MXN.Float64())
+}
+
+func TestCoinbaseProvider_GetPrice_MapsErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		kind   error
+		code   string
+	}{
+		{"not found", http.StatusNotFound, `{"errors":[{"id":"not_found","message":"Invalid base currency"}]}`, models.ErrUnsupportedSymbol, "not_found"},
+		{"rate limited", http.StatusTooManyRequests, `{"errors":[{"id":"rate_limit_exceeded","message":"Too many requests"}]}`, models.ErrRateLimited, "rate_limit_exceeded"},
+		{"unauthorized", http.StatusUnauthorized, `{"errors":[{"id":"invalid_token","message":"bad token"}]}`, models.ErrUnauthorized, "invalid_token"},
+		{"server error without body", http.StatusServiceUnavailable, ``, models.ErrProviderUnavailable, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			p := newTestCoinbaseProvider(server)
+			_, err := p.GetPrice(context.Background(), "BTC")
+
+			require.Error(t, err)
+			assert.ErrorIs(t, err, tt.kind)
+
+			var vErr models.VendorError
+			require.True(t, errors.As(err, &vErr))
+			assert.Equal(t, "coinbase", vErr.Vendor)
+			assert.Equal(t, tt.status, vErr.StatusCode)
+			assert.Equal(t, tt.code, vErr.Code)
+		})
+	}
+}
+
+func TestCoinbaseProvider_GetPrice_InvalidAmount(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"amount":"not_a_number"}}`))
+	}))
+	defer server.Close()
+
+	p := newTestCoinbaseProvider(server)
+	_, err := p.GetPrice(context.Background(), "BTC")
+
+	assert.ErrorIs(t, err, models.ErrBadResponse)
+}
+
+func TestCoinbaseProvider_GetPrice_ConnectionError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	p := newTestCoinbaseProvider(server)
+	_, err := p.GetPrice(context.Background(), "BTC")
+
+	assert.ErrorIs(t, err, models.ErrProviderUnavailable)
+}
+
+func TestCoinbaseProvider_Assets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/currencies/crypto", r.URL.Path)
+		_, _ = w.Write([]byte(`{"data":[
+			{"code":"BTC","name":"Bitcoin","color":"#F7931A","exponent":8,"type":"crypto"},
+			{"code":"SOL","name":"Solana","exponent":9,"type":"crypto"}
+		]}`))
+	}))
+	defer server.Close()
+
+	assets, err := newTestCoinbaseProvider(server).Assets(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []models.Asset{
+		{Symbol: "BTC", Name: "Bitcoin", Decimals: 8},
+		{Symbol: "SOL", Name: "Solana", Decimals: 9},
+	}, assets)
+}
+
+func TestCoinbaseProvider_Assets_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"errors":[{"id":"rate_limit_exceeded","message":"Too many requests"}]}`))
+	}))
+	defer server.Close()
+
+	_, err := newTestCoinbaseProvider(server).Assets(context.Background())
+
+	assert.ErrorIs(t, err, models.ErrRateLimited)
+}
@@ -0,0 +1,239 @@
+package repositories
+
+import (
+	"context"
+	"crypto-aggregator-service/internal/models"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// CoinMarketCapProvider reads aggregated quotes from the CoinMarketCap Pro API.
+// Every call spends credits, so all Currencies are requested in a single call.
+type CoinMarketCapProvider struct {
+	CryptoProvider
+	APIKey     string
+	Currencies []string
+}
+
+func NewCoinMarketCapCryptoProvider(client *http.Client, apiKey string) *CoinMarketCapProvider {
+	return &CoinMarketCapProvider{
+		CryptoProvider: CryptoProvider{
+			BaseURL: "https://pro-api.coinmarketcap.com",
+			Client:  client,
+		},
+		APIKey:     apiKey,
+		Currencies: []string{"USD", "MXN"},
+	}
+}
+
+func (c *CoinMarketCapProvider) Name() string { return "coinmarketcap" }
+
+type cmcStatus struct {
+	ErrorCode    int    `json:"error_code"`
+	ErrorMessage string `json:"error_message"`
+	CreditCount  int    `json:"credit_count"`
+}
+
+type cmcQuotesResp struct {
+	Status cmcStatus `json:"status"`
+	Data   map[string]struct {
+		Symbol string `json:"symbol"`
+		Quote  map[string]struct {
+			Price       models.Decimal `json:"price"` // decoded from the number's digits
+			LastUpdated time.Time      `json:"last_updated"`
+		} `json:"quote"`
+	} `json:"data"`
+}
+
+func (c *CoinMarketCapProvider) GetPrice(ctx context.Context, symbol string) (*models.Money, error) {
+	prices, err := c.GetPrices(ctx, []string{symbol})
+	var symErrs models.SymbolErrors
+	if errors.As(err, &symErrs) {
+		return nil, symErrs[symbol]
+	}
+	if err != nil {
+		return nil, err
+	}
+	return prices[symbol], nil
+}
+
+// GetPrices requests every symbol and every convert currency in one call.
+// CoinMarketCap answers 400 for the whole call when one symbol is unknown;
+// the unknown symbols then fail on their own and the rest are asked again.
+func (c *CoinMarketCapProvider) GetPrices(ctx context.Context, symbols []string) (map[string]*models.Money, error) {
+	prices, err := c.fetchQuotes(ctx, symbols)
+	var vErr models.VendorError
+	if !errors.As(err, &vErr) || vErr.StatusCode != http.StatusBadRequest || !errors.Is(err, models.ErrUnsupportedSymbol) {
+		return prices, err
+	}
+
+	failed := models.SymbolErrors{}
+	invalid := invalidSymbols(vErr.Message)
+	var rest []string
+	for _, symbol := range symbols {
+		if invalid[strings.ToUpper(symbol)] || len(symbols) == 1 {
+			failed[symbol] = vErr
+			continue
+		}
+		rest = append(rest, symbol)
+	}
+
+	prices = make(map[string]*models.Money, len(rest))
+	retry := [][]string{rest}
+	if len(failed) == 0 {
+		// The message did not name the symbols: ask for each one alone
+		retry = retry[:0]
+		for _, symbol := range rest {
+			retry = append(retry, []string{symbol})
+		}
+	}
+	for _, batch := range retry {
+		if len(batch) == 0 {
+			continue
+		}
+		batchPrices, err := c.GetPrices(ctx, batch)
+		var symErrs models.SymbolErrors
+		switch {
+		case errors.As(err, &symErrs):
+			for symbol, symErr := range symErrs {
+				failed[symbol] = symErr
+			}
+		case err != nil:
+			for _, symbol := range batch {
+				failed[symbol] = err
+			}
+		}
+		for symbol, money := range batchPrices {
+			prices[symbol] = money
+		}
+	}
+
+	if len(failed) > 0 {
+		return prices, failed
+	}
+	return prices, nil
+}
+
+// invalidSymbols reads the symbols named by a 400 error message such as
+// `Invalid values for "symbol": "FAKE,NOPE"`.
+func invalidSymbols(message string) map[string]bool {
+	_, list, ok := strings.Cut(message, `"symbol": "`)
+	if !ok {
+		return nil
+	}
+	list, _, _ = strings.Cut(list, `"`)
+	invalid := make(map[string]bool)
+	for _, symbol := range strings.Split(list, ",") {
+		if symbol = strings.ToUpper(strings.TrimSpace(symbol)); symbol != "" {
+			invalid[symbol] = true
+		}
+	}
+	return invalid
+}
+
+// fetchQuotes makes one quotes/latest call.
+func (c *CoinMarketCapProvider) fetchQuotes(ctx context.Context, symbols []string) (map[string]*models.Money, error) {
+	upper := make([]string, len(symbols))
+	for i, symbol := range symbols {
+		upper[i] = strings.ToUpper(symbol)
+	}
+
+	query := url.Values{}
+	query.Set("symbol", strings.Join(upper, ","))
+	query.Set("convert", strings.ToUpper(strings.Join(c.Currencies, ",")))
+
+	endpoint := fmt.Sprintf("%s/v1/cryptocurrency/quotes/latest?%s", c.BaseURL, query.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-CMC_PRO_API_KEY", c.APIKey)
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, transportError(c.Name(), err)
+	}
+	defer resp.Body.Close()
+
+	var result cmcQuotesResp
+	decodeErr := json.NewDecoder(resp.Body).Decode(&result)
+
+	if resp.StatusCode != http.StatusOK || result.Status.ErrorCode != 0 {
+		return nil, c.mapError(resp.StatusCode, result.Status)
+	}
+	if decodeErr != nil {
+		return nil, models.VendorError{Vendor: c.Name(), StatusCode: resp.StatusCode, Message: decodeErr.Error(), Kind: models.ErrBadResponse}
+	}
+
+	prices := make(map[string]*models.Money, len(symbols))
+	failed := models.SymbolErrors{}
+	for i, symbol := range symbols {
+		asset, ok := result.Data[upper[i]]
+		if !ok {
+			failed[symbol] = models.VendorError{Vendor: c.Name(), StatusCode: resp.StatusCode, Message: "no quote for " + upper[i], Kind: models.ErrUnsupportedSymbol}
+			continue
+		}
+
+		money := &models.Money{}
+		for _, currency := range c.Currencies {
+			quote, ok := asset.Quote[strings.ToUpper(currency)]
+			if !ok {
+				failed[symbol] = models.VendorError{Vendor: c.Name(), StatusCode: resp.StatusCode, Message: "missing convert " + currency, Kind: models.ErrBadResponse}
+				break
+			}
+			money.Set(currency, quote.Price)
+			money.SetSourceTime(quote.LastUpdated)
+		}
+		if failed[symbol] == nil {
+			prices[symbol] = money
+		}
+	}
+
+	if len(failed) > 0 {
+		return prices, failed
+	}
+	return prices, nil
+}
+
+// mapError translates the CoinMarketCap status block into a typed VendorError.
+// Credit and plan errors are reported as ErrQuotaExceeded so they are not
+// mistaken for transient outages.
+func (c *CoinMarketCapProvider) mapError(status int, s cmcStatus) error {
+	vErr := models.VendorError{
+		Vendor:     c.Name(),
+		StatusCode: status,
+		Message:    s.ErrorMessage,
+		Kind:       kindForStatus(status),
+	}
+	if s.ErrorCode != 0 {
+		vErr.Code = strconv.Itoa(s.ErrorCode)
+	}
+	if vErr.Message == "" {
+		vErr.Message = fmt.Sprintf("coinmarketcap api status %d", status)
+	}
+
+	switch s.ErrorCode {
+	case http.StatusBadRequest:
+		// Only a rejected "symbol" value is about the symbols; a bad
+		// "convert" or any other parameter is our own request's fault.
+		vErr.Kind = models.ErrBadResponse
+		if strings.Contains(s.ErrorMessage, `"symbol"`) {
+			vErr.Kind = models.ErrUnsupportedSymbol
+		}
+	case 1001, 1002, 1005, 1006, 1007:
+		vErr.Kind = models.ErrUnauthorized
+	case 1003, 1004, 1009, 1010:
+		vErr.Kind = models.ErrQuotaExceeded
+	case 1008, 1011:
+		vErr.Kind = models.ErrRateLimited
+	}
+	return vErr
+}
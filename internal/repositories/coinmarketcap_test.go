@@ -0,0 +1,260 @@
+package repositories
+
+import (
+	"context"
+	"crypto-aggregator-service/internal/models"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCoinMarketCapProvider(server *httptest.Server) *CoinMarketCapProvider {
+	p := NewCoinMarketCapCryptoProvider(server.Client(), "test-key")
+	p.BaseURL = server.URL
+	return p
+}
+
+func TestCoinMarketCapProvider_Name(t *testing.T) {
+	p := NewCoinMarketCapCryptoProvider(http.DefaultClient, "")
+	assert.Equal(t, "coinmarketcap", p.Name())
+}
+
+func TestNewCoinMarketCapCryptoProvider(t *testing.T) {
+	client := &http.Client{}
+	p := NewCoinMarketCapCryptoProvider(client, "secret")
+
+	assert.Equal(t, "https://pro-api.coinmarketcap.com", p.BaseURL)
+	assert.Same(t, client, p.Client)
+	assert.Equal(t, "secret", p.APIKey)
+}
+
+func TestCoinMarketCapProvider_GetPrice_SingleCallWithAllConverts(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		assert.Equal(t, "/v1/cryptocurrency/quotes/latest", r.URL.Path)
+		assert.Equal(t, "BTC", r.URL.Query().Get("symbol"))
+		assert.Equal(t, "USD,MXN", r.URL.Query().Get("convert"))
+		assert.Equal(t, "test-key", r.Header.Get("X-CMC_PRO_API_KEY"))
+		_, _ = w.Write([]byte(`{
+			"status":{"error_code":0,"error_message":null,"credit_count":2},
+			"data":{"BTC":{"symbol":"BTC","quote":{"USD":{"price":50000.5},"MXN":{"price":850000.25}}}}
+		}`))
+	}))
+	defer server.Close()
+
+	p := newTestCoinMarketCapProvider(server)
+	money, err := p.GetPrice(context.Background(), "btc")
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+	assert.InDelta(t, 50000.5, money.USD.Float64(), 0.01)
+	assert.InDelta(t, 850000.25, money.MXN.Float64(), 0.01)
+}
+
+func TestCoinMarketCapProvider_GetPrice_MapsErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		kind   error
+	}{
+		{"invalid key", http.StatusUnauthorized, `{"status":{"error_code":1001,"error_message":"This API Key is invalid."}}`, models.ErrUnauthorized},
+		{"payment required", http.StatusPaymentRequired, `{"status":{"error_code":1004,"error_message":"Your API Key's subscription plan has expired."}}`, models.ErrQuotaExceeded},
+		{"daily credits", http.StatusTooManyRequests, `{"status":{"error_code":1009,"error_message":"You've exceeded your API Key's daily rate limit."}}`, models.ErrQuotaExceeded},
+		{"monthly credits", http.StatusTooManyRequests, `{"status":{"error_code":1010,"error_message":"You've exceeded your API Key's monthly credit limit."}}`, models.ErrQuotaExceeded},
+		{"minute rate limit", http.StatusTooManyRequests, `{"status":{"error_code":1008,"error_message":"You've exceeded your API Key's HTTP request rate limit."}}`, models.ErrRateLimited},
+		{"invalid symbol", http.StatusBadRequest, `{"status":{"timestamp":"2024-05-02T18:04:11.412Z","error_code":400,"error_message":"Invalid value for \"symbol\": \"BTC\"","elapsed":0,"credit_count":0}}`, models.ErrUnsupportedSymbol},
+		{"invalid convert", http.StatusBadRequest, `{"status":{"timestamp":"2024-05-02T18:04:11.412Z","error_code":400,"error_message":"Invalid value for \"convert\": \"XXX\"","elapsed":0,"credit_count":0}}`, models.ErrBadResponse},
+		{"server error", http.StatusInternalServerError, `oops`, models.ErrProviderUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			p := newTestCoinMarketCapProvider(server)
+			_, err := p.GetPrice(context.Background(), "BTC")
+
+			require.Error(t, err)
+			assert.ErrorIs(t, err, tt.kind)
+
+			var vErr models.VendorError
+			require.True(t, errors.As(err, &vErr))
+			assert.Equal(t, "coinmarketcap", vErr.Vendor)
+			assert.Equal(t, tt.status, vErr.StatusCode)
+		})
+	}
+}
+
+func TestCoinMarketCapProvider_GetPrice_QuotaIsNotNetworkError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"status":{"error_code":1010,"error_message":"monthly credit limit"}}`))
+	}))
+
+	p := newTestCoinMarketCapProvider(server)
+	_, quotaErr := p.GetPrice(context.Background(), "BTC")
+	server.Close()
+
+	_, networkErr := p.GetPrice(context.Background(), "BTC")
+
+	assert.ErrorIs(t, quotaErr, models.ErrQuotaExceeded)
+	assert.NotErrorIs(t, quotaErr, models.ErrProviderUnavailable)
+	assert.ErrorIs(t, networkErr, models.ErrProviderUnavailable)
+	assert.NotErrorIs(t, networkErr, models.ErrQuotaExceeded)
+}
+
+func TestCoinMarketCapProvider_GetPrice_MissingSymbolInData(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status":{"error_code":0},"data":{}}`))
+	}))
+	defer server.Close()
+
+	p := newTestCoinMarketCapProvider(server)
+	_, err := p.GetPrice(context.Background(), "BTC")
+
+	assert.ErrorIs(t, err, models.ErrUnsupportedSymbol)
+}
+
+func TestCoinMarketCapProvider_GetPrice_MissingConvert(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status":{"error_code":0},"data":{"BTC":{"quote":{"USD":{"price":1}}}}}`))
+	}))
+	defer server.Close()
+
+	p := newTestCoinMarketCapProvider(server)
+	_, err := p.GetPrice(context.Background(), "BTC")
+
+	assert.ErrorIs(t, err, models.ErrBadResponse)
+}
+
+func TestCoinMarketCapProvider_GetPrices_OneCallForAllSymbols(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		assert.Equal(t, "BTC,ETH,XRP", r.URL.Query().Get("symbol"))
+		_, _ = w.Write([]byte(`{"status":{"error_code":0},"data":{
+			"BTC":{"quote":{"USD":{"price":50000},"MXN":{"price":850000}}},
+			"ETH":{"quote":{"USD":{"price":3000},"MXN":{"price":51000}}}
+		}}`))
+	}))
+	defer server.Close()
+
+	p := newTestCoinMarketCapProvider(server)
+	prices, err := p.GetPrices(context.Background(), []string{"BTC", "ETH", "XRP"})
+
+	assert.Equal(t, 1, calls)
+	var symErrs models.SymbolErrors
+	require.ErrorAs(t, err, &symErrs)
+	assert.ErrorIs(t, symErrs["XRP"], models.ErrUnsupportedSymbol)
+	assert.InDelta(t, 3000.0, prices["ETH"].USD.Float64(), 0.01)
+	assert.InDelta(t, 850000.0, prices["BTC"].MXN.Float64(), 0.01)
+}
+
+// cmcInvalidSymbols mimics quotes/latest: any unknown symbol fails the whole
+// call with 400 and names every unknown symbol.
+func cmcInvalidSymbols(t *testing.T, known map[string]string, calls *[]string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested := strings.Split(r.URL.Query().Get("symbol"), ",")
+		*calls = append(*calls, r.URL.Query().Get("symbol"))
+
+		var unknown, data []string
+		for _, symbol := range requested {
+			price, ok := known[symbol]
+			if !ok {
+				unknown = append(unknown, symbol)
+				continue
+			}
+			data = append(data, `"`+symbol+`":{"quote":{"USD":{"price":`+price+`},"MXN":{"price":1}}}`)
+		}
+		switch {
+		case len(unknown) == 1:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"status":{"timestamp":"2024-05-02T18:04:11.412Z","error_code":400,"error_message":"Invalid value for \"symbol\": \"` + unknown[0] + `\"","elapsed":0,"credit_count":0}}`))
+		case len(unknown) > 1:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"status":{"timestamp":"2024-05-02T18:04:11.412Z","error_code":400,"error_message":"Invalid values for \"symbol\": \"` + strings.Join(unknown, ",") + `\"","elapsed":0,"credit_count":0}}`))
+		default:
+			_, _ = w.Write([]byte(`{"status":{"error_code":0},"data":{` + strings.Join(data, ",") + `}}`))
+		}
+	}))
+}
+
+func TestCoinMarketCapProvider_GetPrices_InvalidSymbolFailsOnlyItself(t *testing.T) {
+	var calls []string
+	server := cmcInvalidSymbols(t, map[string]string{"BTC": "50000", "ETH": "3000"}, &calls)
+	defer server.Close()
+
+	p := newTestCoinMarketCapProvider(server)
+	prices, err := p.GetPrices(context.Background(), []string{"BTC", "FAKE", "ETH", "NOPE"})
+
+	assert.Equal(t, []string{"BTC,FAKE,ETH,NOPE", "BTC,ETH"}, calls)
+	var symErrs models.SymbolErrors
+	require.ErrorAs(t, err, &symErrs)
+	assert.Len(t, symErrs, 2)
+	assert.ErrorIs(t, symErrs["FAKE"], models.ErrUnsupportedSymbol)
+	assert.ErrorIs(t, symErrs["NOPE"], models.ErrUnsupportedSymbol)
+	assert.InDelta(t, 50000.0, prices["BTC"].USD.Float64(), 0.01)
+	assert.InDelta(t, 3000.0, prices["ETH"].USD.Float64(), 0.01)
+}
+
+func TestCoinMarketCapProvider_GetPrices_UnnamedInvalidSymbolAsksOneByOne(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("symbol") == "BTC" {
+			_, _ = w.Write([]byte(`{"status":{"error_code":0},"data":{"BTC":{"quote":{"USD":{"price":50000},"MXN":{"price":1}}}}}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"status":{"error_code":400,"error_message":"Invalid value for \"symbol\""}}`))
+	}))
+	defer server.Close()
+
+	p := newTestCoinMarketCapProvider(server)
+	prices, err := p.GetPrices(context.Background(), []string{"BTC", "FAKE"})
+
+	var symErrs models.SymbolErrors
+	require.ErrorAs(t, err, &symErrs)
+	assert.ErrorIs(t, symErrs["FAKE"], models.ErrUnsupportedSymbol)
+	assert.NotContains(t, symErrs, "BTC")
+	assert.InDelta(t, 50000.0, prices["BTC"].USD.Float64(), 0.01)
+}
+
+func TestCoinMarketCapProvider_GetPrice_InvalidSymbol(t *testing.T) {
+	var calls []string
+	server := cmcInvalidSymbols(t, map[string]string{}, &calls)
+	defer server.Close()
+
+	_, err := newTestCoinMarketCapProvider(server).GetPrice(context.Background(), "FAKE")
+
+	assert.ErrorIs(t, err, models.ErrUnsupportedSymbol)
+	assert.NotErrorIs(t, err, models.ErrProviderUnavailable)
+	assert.Len(t, calls, 1)
+}
+
+func TestCoinMarketCapProvider_GetPrices_InvalidConvertFailsTheBatch(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"status":{"error_code":400,"error_message":"Invalid value for \"convert\": \"XXX\""}}`))
+	}))
+	defer server.Close()
+
+	_, err := newTestCoinMarketCapProvider(server).GetPrices(context.Background(), []string{"BTC", "ETH"})
+
+	var symErrs models.SymbolErrors
+	assert.False(t, errors.As(err, &symErrs), "not a symbol problem")
+	assert.ErrorIs(t, err, models.ErrBadResponse)
+	assert.Equal(t, 1, calls)
+}
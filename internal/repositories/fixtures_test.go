@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"context"
+	"crypto-aggregator-service/internal/adapters/webclients"
+	"crypto-aggregator-service/internal/models"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixtureClient replays testdata/synthetic/<vendor>.json. Those fixtures are
+// hand-written from each vendor's documented responses, not recorded, so the
+// prices and timestamps in them are made up. With RECORD_FIXTURES=1 it calls
+// the real vendor and overwrites the fixture with a recording instead.
+func fixtureClient(t *testing.T, vendor string) *http.Client {
+	t.Helper()
+	path := filepath.Join("testdata", "synthetic", vendor+".json")
+
+	if os.Getenv("RECORD_FIXTURES") != "" {
+		require.NoError(t, os.RemoveAll(path))
+		return webclients.NewClient(10*time.Second, webclients.WithRecorder(path))
+	}
+
+	replayer, err := webclients.LoadReplayer(path)
+	require.NoError(t, err)
+	return webclients.NewClient(0, webclients.WithReplayer(replayer))
+}
+
+func TestFixtures_Bitso(t *testing.T) {
+	p := NewBitsoCryptoProvider(fixtureClient(t, "bitso"))
+
+	prices, err := p.GetPrices(context.Background(), []string{"BTC", "ETH", "XRP", "FAKE"})
+
+	var symErrs models.SymbolErrors
+	require.ErrorAs(t, err, &symErrs)
+	assert.ErrorIs(t, symErrs["FAKE"], models.ErrUnsupportedSymbol)
+
+	assert.InDelta(t, 1118250.50, prices["BTC"].MXN.Float64(), 0.001)
+	assert.InDelta(t, 65012.34, prices["BTC"].USD.Float64(), 0.001)
+	assert.InDelta(t, 58480.00, prices["ETH"].MXN.Float64(), 0.001)
+	assert.Zero(t, prices["ETH"].USD.Float64(), "no eth_usd book")
+	assert.InDelta(t, 8.94, prices["XRP"].MXN.Float64(), 0.001)
+	assert.InDelta(t, 1117600.10, prices["BTC"].Market["mxn"].Bid.Float64(), 0.001)
+	assert.InDelta(t, 1299.90, prices["BTC"].Market["mxn"].Spread.Float64(), 0.001)
+	assert.NotContains(t, prices["ETH"].Market, "usd")
+	assert.Equal(t, time.Date(2024, 5, 2, 15, 4, 5, 0, time.UTC), prices["BTC"].SourceTime.UTC())
+	assert.Equal(t, time.Date(2024, 5, 2, 15, 4, 3, 0, time.UTC), prices["XRP"].SourceTime.UTC())
+}
+
+func TestFixtures_BitsoFX(t *testing.T) {
+	fx := NewBitsoFXProvider(NewBitsoCryptoProvider(fixtureClient(t, "bitso")))
+
+	rate, err := fx.Rate(context.Background(), "USD", "MXN")
+
+	require.NoError(t, err)
+	assert.InDelta(t, 17.20, rate.Float64(), 0.001)
+}
+
+func TestFixtures_Coinbase(t *testing.T) {
+	p := NewCoinbaseCryptoProvider(fixtureClient(t, "coinbase"))
+
+	price, err := p.GetPrice(context.Background(), "BTC")
+	require.NoError(t, err)
+	assert.InDelta(t, 65018.215, price.USD.Float64(), 0.001)
+	assert.InDelta(t, 1118602.76, price.MXN.Float64(), 0.001)
+
+	_, err = p.GetPrice(context.Background(), "FAKE")
+	assert.ErrorIs(t, err, models.ErrUnsupportedSymbol)
+}
+
+func TestFixtures_CoinMarketCap(t *testing.T) {
+	p := NewCoinMarketCapCryptoProvider(fixtureClient(t, "coinmarketcap"), os.Getenv("CMC_API_KEY"))
+
+	prices, err := p.GetPrices(context.Background(), []string{"BTC", "ETH", "FAKE"})
+
+	var symErrs models.SymbolErrors
+	require.ErrorAs(t, err, &symErrs)
+	assert.ErrorIs(t, symErrs["FAKE"], models.ErrUnsupportedSymbol)
+	assert.InDelta(t, 65020.41893, prices["BTC"].USD.Float64(), 0.001)
+	assert.InDelta(t, 1118351.2056, prices["BTC"].MXN.Float64(), 0.001)
+	assert.InDelta(t, 3401.77123, prices["ETH"].USD.Float64(), 0.001)
+	assert.Equal(t, time.Date(2024, 5, 2, 15, 3, 0, 0, time.UTC), prices["BTC"].SourceTime)
+}
+
+func TestFixtures_Binance(t *testing.T) {
+	p := NewBinanceCryptoProvider(fixtureClient(t, "binance"))
+
+	prices, err := p.GetPrices(context.Background(), []string{"BTC", "ETH"})
+	require.NoError(t, err)
+	assert.InDelta(t, 65011.99, prices["BTC"].USD.Float64(), 0.001)
+	assert.InDelta(t, 3400.52, prices["ETH"].USD.Float64(), 0.001)
+
+	_, err = p.GetPrice(context.Background(), "FAKE")
+	assert.ErrorIs(t, err, models.ErrUnsupportedSymbol)
+}
+
+func TestFixtures_Kraken(t *testing.T) {
+	p := NewKrakenCryptoProvider(fixtureClient(t, "kraken"))
+
+	price, err := p.GetPrice(context.Background(), "BTC")
+	require.NoError(t, err)
+	assert.InDelta(t, 65021.10, price.USD.Float64(), 0.001)
+	assert.InDelta(t, 2011.48291022, price.Volume, 0.000001)
+
+	_, err = p.GetPrice(context.Background(), "FAKE")
+	assert.ErrorIs(t, err, models.ErrUnsupportedSymbol)
+}
+
+func TestFixtures_UnrecordedRequestFails(t *testing.T) {
+	p := NewKrakenCryptoProvider(fixtureClient(t, "kraken"))
+
+	_, err := p.GetPrice(context.Background(), "ETH")
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), webclients.ErrNoFixture.Error())
+}
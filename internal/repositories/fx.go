@@ -0,0 +1,173 @@
+package repositories
+
+import (
+	"context"
+	"crypto-aggregator-service/internal/models"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// FXProvider converts between fiat currencies.
+type FXProvider interface {
+	// Rate returns how many units of `to` one unit of `from` is worth.
+	Rate(ctx context.Context, from, to string) (models.Decimal, error)
+}
+
+// crossRate derives from -> to out of a table of units-per-USD.
+func crossRate(perUSD map[string]models.Decimal, from, to string) (models.Decimal, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return models.DecimalFromInt(1), nil
+	}
+
+	fromRate, ok := perUSD[from]
+	if !ok || fromRate.Sign() <= 0 {
+		return models.Decimal{}, fmt.Errorf("%w: %s", models.ErrNoFXRate, from)
+	}
+	toRate, ok := perUSD[to]
+	if !ok || toRate.Sign() <= 0 {
+		return models.Decimal{}, fmt.Errorf("%w: %s", models.ErrNoFXRate, to)
+	}
+	return toRate.Quo(fromRate), nil
+}
+
+// BitsoFXProvider derives fiat rates from Bitso books. It prefers direct
+// usd_<fiat> books and triangulates through btc_<fiat>/btc_usd otherwise.
+// Rates are cached for TTL; if a refresh fails the previous rates are kept.
+type BitsoFXProvider struct {
+	Bitso *BitsoProvider
+	TTL   time.Duration
+
+	mu        sync.Mutex
+	perUSD    map[string]models.Decimal
+	fetchedAt time.Time
+}
+
+func NewBitsoFXProvider(bitso *BitsoProvider) *BitsoFXProvider {
+	return &BitsoFXProvider{
+		Bitso: bitso,
+		TTL:   time.Minute,
+	}
+}
+
+func (f *BitsoFXProvider) Rate(ctx context.Context, from, to string) (models.Decimal, error) {
+	perUSD, err := f.rates(ctx)
+	if err != nil {
+		return models.Decimal{}, err
+	}
+	return crossRate(perUSD, from, to)
+}
+
+func (f *BitsoFXProvider) rates(ctx context.Context) (map[string]models.Decimal, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.perUSD != nil && time.Since(f.fetchedAt) < f.TTL {
+		return f.perUSD, nil
+	}
+
+	books, err := f.Bitso.tickers(ctx)
+	if err != nil {
+		if f.perUSD != nil {
+			return f.perUSD, nil
+		}
+		return nil, err
+	}
+
+	f.perUSD, f.fetchedAt = usdRatesFromBooks(books), time.Now()
+	return f.perUSD, nil
+}
+
+// usdRatesFromBooks builds a units-per-USD table from a Bitso ticker snapshot.
+func usdRatesFromBooks(books map[string]bitsoTicker) map[string]models.Decimal {
+	last := func(book string) (models.Decimal, bool) {
+		t, ok := books[book]
+		if !ok {
+			return models.Decimal{}, false
+		}
+		v, err := models.ParseDecimal(t.Last)
+		return v, err == nil && v.Sign() > 0
+	}
+
+	perUSD := map[string]models.Decimal{"USD": models.DecimalFromInt(1)}
+	btcUSD, hasBTCUSD := last("btc_usd")
+
+	for name := range books {
+		base, quote, ok := strings.Cut(name, "_")
+		if !ok {
+			continue
+		}
+		fiat := strings.ToUpper(quote)
+
+		switch base {
+		case "usd":
+			if v, ok := last(name); ok {
+				perUSD[fiat] = v
+			}
+		case "btc":
+			if _, direct := books["usd_"+quote]; direct || !hasBTCUSD || fiat == "USD" {
+				continue
+			}
+			if v, ok := last(name); ok {
+				perUSD[fiat] = v.Quo(btcUSD)
+			}
+		}
+	}
+	return perUSD
+}
+
+// StaticFXProvider serves fixed rates, typically loaded from a file.
+type StaticFXProvider struct {
+	perUSD map[string]models.Decimal
+}
+
+// staticRatesFile is the on-disk format: units of each currency per 1 USD.
+type staticRatesFile struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// NewStaticFXProvider builds a provider from units-per-USD rates. Each rate is
+// taken at its shortest decimal form, so 17.2 is exactly 17.2.
+func NewStaticFXProvider(perUSD map[string]float64) *StaticFXProvider {
+	rates := map[string]models.Decimal{"USD": models.DecimalFromInt(1)}
+	for currency, rate := range perUSD {
+		rates[strings.ToUpper(currency)] = models.DecimalFromFloat(rate)
+	}
+	return &StaticFXProvider{perUSD: rates}
+}
+
+// LoadStaticFXProvider reads a JSON file like {"base":"USD","rates":{"MXN":17.1}}.
+func LoadStaticFXProvider(path string) (*StaticFXProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file staticRatesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid fx rates file %s: %w", path, err)
+	}
+	if file.Base != "" && !strings.EqualFold(file.Base, "USD") {
+		return nil, fmt.Errorf("invalid fx rates file %s: base must be USD, got %s", path, file.Base)
+	}
+	for currency, rate := range file.Rates {
+		d, err := models.NewDecimalFromFloat(rate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fx rates file %s: %s: %w", path, currency, err)
+		}
+		if d.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid fx rates file %s: %s rate must be positive, got %s", path, currency, d)
+		}
+	}
+	return NewStaticFXProvider(file.Rates), nil
+}
+
+func (f *StaticFXProvider) Rate(ctx context.Context, from, to string) (models.Decimal, error) {
+	return crossRate(f.perUSD, from, to)
+}
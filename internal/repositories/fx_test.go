@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"context"
+	"crypto-aggregator-service/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitsoFXProvider_DirectUSDBook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"success":true,"payload":[
+			{"book":"usd_mxn","last":"17.50"},
+			{"book":"btc_mxn","last":"900000"},{"book":"btc_usd","last":"50000"}
+		]}`))
+	}))
+	defer server.Close()
+
+	fx := NewBitsoFXProvider(newTestProvider(server))
+
+	rate, err := fx.Rate(context.Background(), "USD", "MXN")
+	require.NoError(t, err)
+	// The direct book wins over the 900000/50000 = 18 triangulation
+	assert.InDelta(t, 17.50, rate.Float64(), 0.0001)
+
+	rate, err = fx.Rate(context.Background(), "MXN", "USD")
+	require.NoError(t, err)
+	assert.InDelta(t, 1/17.50, rate.Float64(), 0.0001)
+}
+
+func TestBitsoFXProvider_TriangulatesThroughBTC(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"success":true,"payload":[
+			{"book":"btc_mxn","last":"900000"},{"book":"btc_usd","last":"50000"},
+			{"book":"btc_brl","last":"250000"}
+		]}`))
+	}))
+	defer server.Close()
+
+	fx := NewBitsoFXProvider(newTestProvider(server))
+
+	rate, err := fx.Rate(context.Background(), "USD", "MXN")
+	require.NoError(t, err)
+	assert.InDelta(t, 18.0, rate.Float64(), 0.0001)
+
+	rate, err = fx.Rate(context.Background(), "MXN", "BRL")
+	require.NoError(t, err)
+	assert.InDelta(t, 5.0/18.0, rate.Float64(), 0.0001)
+}
+
+func TestBitsoFXProvider_UnknownCurrency(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"success":true,"payload":[{"book":"usd_mxn","last":"17.5"}]}`))
+	}))
+	defer server.Close()
+
+	fx := NewBitsoFXProvider(newTestProvider(server))
+	_, err := fx.Rate(context.Background(), "USD", "EUR")
+
+	assert.ErrorIs(t, err, models.ErrNoFXRate)
+}
+
+func TestBitsoFXProvider_CachesAndKeepsRatesOnFailure(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) > 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"success":true,"payload":[{"book":"usd_mxn","last":"17.5"}]}`))
+	}))
+	defer server.Close()
+
+	bitso := newTestProvider(server)
+	bitso.TickerTTL = 0
+	fx := NewBitsoFXProvider(bitso)
+
+	_, err := fx.Rate(context.Background(), "USD", "MXN")
+	require.NoError(t, err)
+	_, err = fx.Rate(context.Background(), "USD", "MXN")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), calls.Load(), "rates should be served from cache within TTL")
+
+	fx.TTL = 0
+	rate, err := fx.Rate(context.Background(), "USD", "MXN")
+	require.NoError(t, err)
+	assert.InDelta(t, 17.5, rate.Float64(), 0.0001)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestBitsoFXProvider_FailsWithoutPreviousRates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	fx := NewBitsoFXProvider(newTestProvider(server))
+	_, err := fx.Rate(context.Background(), "USD", "MXN")
+
+	assert.ErrorIs(t, err, models.ErrProviderUnavailable)
+}
+
+func TestLoadStaticFXProvider(t *testing.T) {
+	fx, err := LoadStaticFXProvider("testdata/fx_rates.json")
+	require.NoError(t, err)
+
+	rate, err := fx.Rate(context.Background(), "usd", "mxn")
+	require.NoError(t, err)
+	assert.InDelta(t, 17.0, rate.Float64(), 0.0001)
+
+	rate, err = fx.Rate(context.Background(), "EUR", "MXN")
+	require.NoError(t, err)
+	assert.InDelta(t, 17.0/0.9, rate.Float64(), 0.0001)
+
+	rate, err = fx.Rate(context.Background(), "MXN", "MXN")
+	require.NoError(t, err)
+	assert.Equal(t, "1", rate.String())
+}
+
+func TestLoadStaticFXProvider_MissingFile(t *testing.T) {
+	_, err := LoadStaticFXProvider("testdata/does_not_exist.json")
+	assert.Error(t, err)
+}
+
+func TestLoadStaticFXProvider_RejectsUnusableRates(t *testing.T) {
+	for name, rates := range map[string]string{
+		"out of range": `{"base":"USD","rates":{"MXN":1e300}}`,
+		"zero":         `{"base":"USD","rates":{"MXN":0}}`,
+		"negative":     `{"base":"USD","rates":{"MXN":-17}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "fx.json")
+			require.NoError(t, os.WriteFile(path, []byte(rates), 0o600))
+
+			_, err := LoadStaticFXProvider(path)
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "MXN")
+		})
+	}
+}
+
+func TestStaticFXProvider_UnknownCurrency(t *testing.T) {
+	fx := NewStaticFXProvider(map[string]float64{"MXN": 17})
+	_, err := fx.Rate(context.Background(), "USD", "ARS")
+	assert.ErrorIs(t, err, models.ErrNoFXRate)
+}
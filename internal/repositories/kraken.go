@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"context"
+	"crypto-aggregator-service/internal/models"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// KrakenProvider reads last trade prices from the Kraken public REST API.
+// Kraken uses its own asset codes (XBT for BTC), resolved through Aliases.
+type KrakenProvider struct {
+	CryptoProvider
+	Currencies []string
+	Aliases    SymbolAliases
+}
+
+func NewKrakenCryptoProvider(client *http.Client) *KrakenProvider {
+	return &KrakenProvider{
+		CryptoProvider: CryptoProvider{
+			BaseURL: "https://api.kraken.com",
+			Client:  client,
+		},
+		Currencies: []string{"USD"},
+		Aliases:    AliasesFor("kraken"),
+	}
+}
+
+func (c *KrakenProvider) Name() string { return "kraken" }
+
+type krakenTickerResp struct {
+	Error  []string `json:"error"`
+	Result map[string]struct {
+		// Last trade closed: [price, lot volume]
+		Close []string `json:"c"`
+		// Volume: [today, last 24 hours]
+		Volume []string `json:"v"`
+	} `json:"result"`
+}
+
+func (c *KrakenProvider) GetPrice(ctx context.Context, symbol string) (*models.Money, error) {
+	money := &models.Money{}
+	for _, currency := range c.Currencies {
+		price, volume, err := c.fetchPair(ctx, c.Aliases.Resolve(symbol)+c.Aliases.Resolve(currency))
+		if err != nil {
+			return nil, err
+		}
+		money.Set(currency, price)
+		money.Volume = volume
+	}
+	return money, nil
+}
+
+// fetchPair returns the last price and the 24h volume of a pair.
+func (c *KrakenProvider) fetchPair(ctx context.Context, pair string) (models.Decimal, float64, error) {
+	endpoint := fmt.Sprintf("%s/0/public/Ticker?pair=%s", c.BaseURL, url.QueryEscape(pair))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return models.Decimal{}, 0, err
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return models.Decimal{}, 0, transportError(c.Name(), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return models.Decimal{}, 0, models.VendorError{
+			Vendor:     c.Name(),
+			StatusCode: resp.StatusCode,
+			Message:    fmt.Sprintf("kraken api status %d", resp.StatusCode),
+			Kind:       kindForStatus(resp.StatusCode),
+		}
+	}
+
+	var result krakenTickerResp
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return models.Decimal{}, 0, models.VendorError{Vendor: c.Name(), StatusCode: resp.StatusCode, Message: err.Error(), Kind: models.ErrBadResponse}
+	}
+
+	// Kraken reports failures with HTTP 200 and a non-empty error list.
+	if len(result.Error) > 0 {
+		return models.Decimal{}, 0, c.mapError(resp.StatusCode, result.Error[0])
+	}
+
+	// The result is keyed by Kraken's internal pair name (XBTUSD -> XXBTZUSD),
+	// so take the only entry instead of guessing the key.
+	for _, ticker := range result.Result {
+		if len(ticker.Close) == 0 {
+			break
+		}
+		price, err := models.ParseDecimal(ticker.Close[0])
+		if err != nil {
+			return models.Decimal{}, 0, models.VendorError{Vendor: c.Name(), StatusCode: resp.StatusCode, Message: err.Error(), Kind: models.ErrBadResponse}
+		}
+		var volume float64
+		if len(ticker.Volume) > 1 {
+			volume, _ = strconv.ParseFloat(ticker.Volume[1], 64)
+		}
+		return price, volume, nil
+	}
+	return models.Decimal{}, 0, models.VendorError{Vendor: c.Name(), StatusCode: resp.StatusCode, Message: "empty ticker for " + pair, Kind: models.ErrBadResponse}
+}
+
+// mapError classifies Kraken error strings such as "EQuery:Unknown asset pair".
+func (c *KrakenProvider) mapError(status int, message string) error {
+	vErr := models.VendorError{
+		Vendor:     c.Name(),
+		StatusCode: status,
+		Code:       message,
+		Message:    message,
+		Kind:       models.ErrBadResponse,
+	}
+	if i := strings.Index(message, ":"); i > 0 {
+		vErr.Code = message[:i]
+	}
+
+	switch {
+	case strings.HasPrefix(message, "EQuery:Unknown asset pair"):
+		vErr.Kind = models.ErrUnsupportedSymbol
+	case strings.Contains(message, "Rate limit") || strings.Contains(message, "Too many requests"):
+		vErr.Kind = models.ErrRateLimited
+	case strings.HasPrefix(message, "EService:"):
+		vErr.Kind = models.ErrProviderUnavailable
+	case strings.HasPrefix(message, "EAPI:Invalid key"):
+		vErr.Kind = models.ErrUnauthorized
+	}
+	return vErr
+}
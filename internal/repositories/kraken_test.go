@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"context"
+	"crypto-aggregator-service/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKrakenProvider(server *httptest.Server) *KrakenProvider {
+	p := NewKrakenCryptoProvider(server.Client())
+	p.BaseURL = server.URL
+	return p
+}
+
+func TestKrakenProvider_Name(t *testing.T) {
+	p := NewKrakenCryptoProvider(http.DefaultClient)
+	assert.Equal(t, "kraken", p.Name())
+	assert.Equal(t, "https://api.kraken.com", p.BaseURL)
+}
+
+func TestKrakenProvider_GetPrice_TranslatesBTCToXBT(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/0/public/Ticker", r.URL.Path)
+		assert.Equal(t, "XBTUSD", r.URL.Query().Get("pair"))
+		_, _ = w.Write([]byte(`{"error":[],"result":{"XXBTZUSD":{"c":["50000.10000","0.0015"]}}}`))
+	}))
+	defer server.Close()
+
+	p := newTestKrakenProvider(server)
+	money, err := p.GetPrice(context.Background(), "BTC")
+
+	require.NoError(t, err)
+	assert.InDelta(t, 50000.10, money.USD.Float64(), 0.001)
+}
+
+func TestKrakenProvider_GetPrice_CanonicalSymbolPassesThrough(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "ETHUSD", r.URL.Query().Get("pair"))
+		_, _ = w.Write([]byte(`{"error":[],"result":{"XETHZUSD":{"c":["3000.5","1"]}}}`))
+	}))
+	defer server.Close()
+
+	p := newTestKrakenProvider(server)
+	money, err := p.GetPrice(context.Background(), "eth")
+
+	require.NoError(t, err)
+	assert.InDelta(t, 3000.5, money.USD.Float64(), 0.001)
+}
+
+func TestKrakenProvider_GetPrice_MapsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		kind error
+	}{
+		{"unknown pair", `{"error":["EQuery:Unknown asset pair"]}`, models.ErrUnsupportedSymbol},
+		{"rate limit", `{"error":["EAPI:Rate limit exceeded"]}`, models.ErrRateLimited},
+		{"service unavailable", `{"error":["EService:Unavailable"]}`, models.ErrProviderUnavailable},
+		{"empty result", `{"error":[],"result":{}}`, models.ErrBadResponse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			p := newTestKrakenProvider(server)
+			_, err := p.GetPrice(context.Background(), "BTC")
+
+			assert.ErrorIs(t, err, tt.kind)
+		})
+	}
+}
+
+func TestKrakenProvider_GetPrice_Non200Status(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	p := newTestKrakenProvider(server)
+	_, err := p.GetPrice(context.Background(), "BTC")
+
+	assert.ErrorIs(t, err, models.ErrProviderUnavailable)
+}
+
+func TestKrakenProvider_GetPrice_ReportsVolume(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"error":[],"result":{"XXBTZUSD":{"c":["50000","0.1"],"v":["100.5","2500.25"]}}}`))
+	}))
+	defer server.Close()
+
+	p := newTestKrakenProvider(server)
+	money, err := p.GetPrice(context.Background(), "BTC")
+
+	require.NoError(t, err)
+	assert.InDelta(t, 2500.25, money.Volume, 0.001)
+}
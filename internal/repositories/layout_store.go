@@ -38,3 +38,19 @@ func (s *LayoutStore) UpdateModel(index int, model interface{}) {
 		s.layout[index].Model = model
 	}
 }
+
+// UpdateStatus replaces the status of a specific component with the result of
+// update, which receives the current one (the zero value when unset).
+func (s *LayoutStore) UpdateStatus(index int, update func(models.ComponentStatus) models.ComponentStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if index < 0 || index >= len(s.layout) {
+		return
+	}
+	var current models.ComponentStatus
+	if s.layout[index].Status != nil {
+		current = *s.layout[index].Status
+	}
+	next := update(current)
+	s.layout[index].Status = &next
+}
@@ -0,0 +1,232 @@
+package repositories
+
+import (
+	"crypto-aggregator-service/internal/adapters"
+	"crypto-aggregator-service/internal/models"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// VendorConfig is the configuration section of one vendor instance.
+type VendorConfig struct {
+	// Type selects the factory; it defaults to the vendor name so built-in
+	// vendors need no explicit type.
+	Type    string
+	BaseURL string // overrides the vendor default when set
+	Timeout time.Duration
+	Key     string
+	Secret  string
+	// Currencies replaces the fiat quotes the vendor requests by default.
+	Currencies []string
+	// RateLimit paces outbound requests; zero RPS disables it.
+	RateLimit RateLimit
+	// Retry resends failed requests when MaxAttempts > 1.
+	Retry RetryConfig
+	// Breaker guards the vendor with a circuit breaker when FailureThreshold > 0.
+	Breaker BreakerConfig
+	REST    RESTSpec   // only used by the "rest" type
+	Mock    MockConfig // only used by the "mock" type
+}
+
+// MockConfig tunes the "mock" type. Any value set switches it from plain
+// random prices to a seeded random walk.
+type MockConfig struct {
+	Seed       int64
+	Prices     map[string]float64 // USD starting price per symbol
+	Volatility float64
+	Latency    time.Duration
+	ErrorRate  float64
+	Replay     string // CSV of symbol,usd,mxn served in order
+}
+
+// IsZero reports whether no mock setting was configured.
+func (c MockConfig) IsZero() bool {
+	return c.Seed == 0 && len(c.Prices) == 0 && c.Volatility == 0 && c.Latency == 0 && c.ErrorRate == 0 && c.Replay == ""
+}
+
+// RateLimit is a token bucket: RPS requests per second with bursts of Burst.
+type RateLimit struct {
+	RPS   float64
+	Burst int
+}
+
+// RetryConfig bounds the attempts of one request and the backoff between them.
+type RetryConfig struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Factory builds a vendor client from its configuration. client already
+// carries the configured timeout, rate limit and retries.
+type Factory func(name string, cfg VendorConfig, client *http.Client) (CryptoClient, error)
+
+// Registry maps vendor types to the factories that build them.
+type Registry struct {
+	newHTTPClient func(vendor string, cfg VendorConfig) *http.Client
+	factories     map[string]Factory
+}
+
+// NewRegistry returns an empty registry. newHTTPClient builds the HTTP client
+// handed to every factory from the vendor configuration.
+func NewRegistry(newHTTPClient func(vendor string, cfg VendorConfig) *http.Client) *Registry {
+	return &Registry{newHTTPClient: newHTTPClient, factories: make(map[string]Factory)}
+}
+
+// NewDefaultRegistry returns a registry with every vendor implemented in this
+// package plus the generic "rest" and "mock" types.
+func NewDefaultRegistry(newHTTPClient func(vendor string, cfg VendorConfig) *http.Client) *Registry {
+	r := NewRegistry(newHTTPClient)
+
+	r.Register("bitso", func(name string, cfg VendorConfig, client *http.Client) (CryptoClient, error) {
+		p := NewBitsoCryptoProvider(client)
+		cfg.applyBaseURL(&p.CryptoProvider)
+		cfg.applyCurrencies(&p.Currencies)
+		return p, nil
+	})
+	r.Register("coinbase", func(name string, cfg VendorConfig, client *http.Client) (CryptoClient, error) {
+		p := NewCoinbaseCryptoProvider(client)
+		cfg.applyBaseURL(&p.CryptoProvider)
+		cfg.applyCurrencies(&p.Currencies)
+		return p, nil
+	})
+	r.Register("coinmarketcap", func(name string, cfg VendorConfig, client *http.Client) (CryptoClient, error) {
+		p := NewCoinMarketCapCryptoProvider(client, cfg.Key)
+		cfg.applyBaseURL(&p.CryptoProvider)
+		cfg.applyCurrencies(&p.Currencies)
+		return p, nil
+	})
+	r.Register("binance", func(name string, cfg VendorConfig, client *http.Client) (CryptoClient, error) {
+		p := NewBinanceCryptoProvider(client)
+		cfg.applyBaseURL(&p.CryptoProvider)
+		cfg.applyCurrencies(&p.Currencies)
+		return p, nil
+	})
+	r.Register("kraken", func(name string, cfg VendorConfig, client *http.Client) (CryptoClient, error) {
+		p := NewKrakenCryptoProvider(client)
+		cfg.applyBaseURL(&p.CryptoProvider)
+		cfg.applyCurrencies(&p.Currencies)
+		return p, nil
+	})
+	r.Register("rest", func(name string, cfg VendorConfig, client *http.Client) (CryptoClient, error) {
+		spec := cfg.REST
+		spec.Name, spec.BaseURL = name, cfg.BaseURL
+		spec.Key, spec.Secret = cfg.Key, cfg.Secret
+		return NewRESTCryptoProvider(client, spec)
+	})
+	r.Register("mock", func(name string, cfg VendorConfig, client *http.Client) (CryptoClient, error) {
+		return newMockClient(cfg.Mock)
+	})
+	return r
+}
+
+func newMockClient(cfg MockConfig) (CryptoClient, error) {
+	if cfg.IsZero() {
+		return &adapters.MockClient{}, nil
+	}
+	for symbol, price := range cfg.Prices {
+		d, err := models.NewDecimalFromFloat(price)
+		if err != nil {
+			return nil, fmt.Errorf("mock price %s: %w", symbol, err)
+		}
+		if d.Sign() <= 0 {
+			return nil, fmt.Errorf("mock price %s must be positive, got %s", symbol, d)
+		}
+	}
+
+	opts := []adapters.MockOption{
+		adapters.WithSeed(cfg.Seed),
+		adapters.WithBasePrices(cfg.Prices),
+		adapters.WithLatency(cfg.Latency),
+		adapters.WithErrorRate(cfg.ErrorRate),
+	}
+	if cfg.Volatility > 0 {
+		opts = append(opts, adapters.WithVolatility(cfg.Volatility))
+	}
+	if cfg.Replay != "" {
+		replay, err := adapters.LoadMockReplay(cfg.Replay)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, adapters.WithReplay(replay))
+	}
+	return adapters.NewMockClient(opts...), nil
+}
+
+func (c VendorConfig) applyBaseURL(p *CryptoProvider) {
+	if c.BaseURL != "" {
+		p.BaseURL = c.BaseURL
+	}
+}
+
+func (c VendorConfig) applyCurrencies(currencies *[]string) {
+	if len(c.Currencies) > 0 {
+		*currencies = c.Currencies
+	}
+}
+
+// Register adds or replaces the factory of a vendor type.
+func (r *Registry) Register(vendorType string, f Factory) {
+	r.factories[vendorType] = f
+}
+
+// Types lists the registered vendor types, sorted.
+func (r *Registry) Types() []string {
+	types := make([]string, 0, len(r.factories))
+	for t := range r.factories {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Build instantiates one vendor.
+func (r *Registry) Build(name string, cfg VendorConfig) (CryptoClient, error) {
+	vendorType := cfg.Type
+	if vendorType == "" {
+		vendorType = name
+	}
+
+	factory, ok := r.factories[vendorType]
+	if !ok {
+		if cfg.Type == "" {
+			return nil, fmt.Errorf("vendor %q: unknown vendor, set a type (known: %s)", name, strings.Join(r.Types(), ", "))
+		}
+		return nil, fmt.Errorf("vendor %q: unknown type %q (known: %s)", name, cfg.Type, strings.Join(r.Types(), ", "))
+	}
+
+	client, err := factory(name, cfg, r.newHTTPClient(name, cfg))
+	if err != nil {
+		return nil, fmt.Errorf("vendor %q: %w", name, err)
+	}
+	return client, nil
+}
+
+// BuildAll instantiates every configured vendor, keyed by its configured
+// name. Every failing vendor is reported, not only the first one.
+func (r *Registry) BuildAll(configs map[string]VendorConfig) (map[string]CryptoClient, error) {
+	names := make([]string, 0, len(configs))
+	for name := range configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	clients := make(map[string]CryptoClient, len(configs))
+	var errs []error
+	for _, name := range names {
+		client, err := r.Build(name, configs[name])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		clients[name] = client
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return clients, nil
+}
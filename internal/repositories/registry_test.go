@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"context"
+	"crypto-aggregator-service/internal/adapters"
+	"crypto-aggregator-service/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHTTPClient(vendor string, cfg VendorConfig) *http.Client {
+	return &http.Client{Timeout: cfg.Timeout}
+}
+
+func TestRegistry_BuildAll_BuiltIns(t *testing.T) {
+	r := NewDefaultRegistry(newTestHTTPClient)
+
+	clients, err := r.BuildAll(map[string]VendorConfig{
+		"bitso":         {},
+		"coinmarketcap": {Key: "cmc-key", Timeout: 5 * time.Second},
+		"kraken-eu":     {Type: "kraken", BaseURL: "https://kraken.internal"},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, clients, 3)
+
+	bitso, ok := clients["bitso"].(*BitsoProvider)
+	require.True(t, ok)
+	assert.Equal(t, "https://api.bitso.com", bitso.BaseURL)
+
+	cmc, ok := clients["coinmarketcap"].(*CoinMarketCapProvider)
+	require.True(t, ok)
+	assert.Equal(t, "cmc-key", cmc.APIKey)
+	assert.Equal(t, 5*time.Second, cmc.Client.Timeout)
+
+	kraken, ok := clients["kraken-eu"].(*KrakenProvider)
+	require.True(t, ok)
+	assert.Equal(t, "https://kraken.internal", kraken.BaseURL)
+}
+
+func TestRegistry_BuildAll_RESTVendor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/price/BTC", r.URL.Path)
+		_, _ = w.Write([]byte(`{"price":"50000"}`))
+	}))
+	defer server.Close()
+
+	r := NewDefaultRegistry(newTestHTTPClient)
+	clients, err := r.BuildAll(map[string]VendorConfig{
+		"internal": {Type: "rest", BaseURL: server.URL, REST: RESTSpec{URL: "{base_url}/price/{symbol}", PricePath: "price"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "internal", clients["internal"].Name())
+
+	money, err := clients["internal"].GetPrice(context.Background(), "BTC")
+	require.NoError(t, err)
+	assert.InDelta(t, 50000.0, money.USD.Float64(), 0.001)
+}
+
+func TestRegistry_BuildAll_RESTVendorCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "internal-key", r.Header.Get("X-Api-Key"))
+		_, _ = w.Write([]byte(`{"price":"50000"}`))
+	}))
+	defer server.Close()
+
+	r := NewDefaultRegistry(newTestHTTPClient)
+	clients, err := r.BuildAll(map[string]VendorConfig{
+		"internal": {Type: "rest", BaseURL: server.URL, Key: "internal-key", REST: RESTSpec{
+			URL:       "{base_url}/price/{symbol}",
+			Headers:   map[string]string{"X-Api-Key": "{key}"},
+			PricePath: "price",
+		}},
+	})
+	require.NoError(t, err)
+
+	_, err = clients["internal"].GetPrice(context.Background(), "BTC")
+	require.NoError(t, err)
+}
+
+func TestRegistry_BuildAll_MockVendor(t *testing.T) {
+	r := NewDefaultRegistry(newTestHTTPClient)
+
+	clients, err := r.BuildAll(map[string]VendorConfig{
+		"mock":   {},
+		"seeded": {Type: "mock", Mock: MockConfig{Seed: 7, Prices: map[string]float64{"BTC": 100}}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, &adapters.MockClient{}, clients["mock"], "unconfigured mock keeps its legacy prices")
+
+	seeded, err := clients["seeded"].GetPrice(context.Background(), "BTC")
+	require.NoError(t, err)
+	assert.InDelta(t, 100, seeded.USD.Float64(), 5)
+}
+
+func TestRegistry_BuildAll_MockRejectsUnusablePrices(t *testing.T) {
+	r := NewDefaultRegistry(newTestHTTPClient)
+
+	for _, price := range []float64{1e300, 0, -1} {
+		_, err := r.BuildAll(map[string]VendorConfig{
+			"mock": {Mock: MockConfig{Prices: map[string]float64{"BTC": price}}},
+		})
+		require.Error(t, err, "%v", price)
+		assert.Contains(t, err.Error(), "mock price BTC")
+	}
+}
+
+func TestRegistry_BuildAll_MockReplayMissing(t *testing.T) {
+	r := NewDefaultRegistry(newTestHTTPClient)
+
+	_, err := r.BuildAll(map[string]VendorConfig{
+		"mock": {Mock: MockConfig{Replay: "testdata/missing.csv"}},
+	})
+
+	assert.Error(t, err)
+}
+
+func TestRegistry_BuildAll_ReportsEveryUnknownVendor(t *testing.T) {
+	r := NewDefaultRegistry(newTestHTTPClient)
+
+	_, err := r.BuildAll(map[string]VendorConfig{
+		"bitso":    {},
+		"bitfinex": {},
+		"ftx":      {Type: "exchange"},
+		"broken":   {Type: "rest"},
+	})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `vendor "bitfinex": unknown vendor, set a type (known: binance, bitso, coinbase, coinmarketcap, kraken, mock, rest)`)
+	assert.Contains(t, err.Error(), `vendor "ftx": unknown type "exchange"`)
+	assert.Contains(t, err.Error(), `vendor "broken": rest vendor broken: url is required`)
+}
+
+func TestRegistry_Register_CustomFactory(t *testing.T) {
+	r := NewRegistry(newTestHTTPClient)
+	r.Register("fixed", func(name string, cfg VendorConfig, client *http.Client) (CryptoClient, error) {
+		return fixedClient{name: name}, nil
+	})
+
+	client, err := r.Build("fixed", VendorConfig{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "fixed", client.Name())
+	assert.Equal(t, []string{"fixed"}, r.Types())
+}
+
+type fixedClient struct{ name string }
+
+func (f fixedClient) Name() string { return f.name }
+
+func (f fixedClient) GetPrice(ctx context.Context, symbol string) (*models.Money, error) {
+	return &models.Money{USD: models.DecimalFromFloat(1)}, nil
+}
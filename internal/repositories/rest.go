@@ -0,0 +1,298 @@
+package repositories
+
+import (
+	"context"
+	"crypto-aggregator-service/internal/models"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// RESTSpec describes a JSON price endpoint so a vendor can be onboarded from
+// configuration alone.
+//
+// URL, Headers and the JSON paths accept the placeholders {symbol} and {quote}
+// (after Aliases and Case are applied), {base_url}, and {key} and {secret} for
+// the vendor credentials, e.g. a header "Authorization: Bearer {key}".
+type RESTSpec struct {
+	Name    string
+	BaseURL string
+	URL     string // e.g. "{base_url}/price?pair={symbol}-{quote}"
+	Headers map[string]string
+	Key     string // credentials, never written in URL or Headers directly
+	Secret  string
+	// Currencies are the fiat quotes requested for every symbol, one request each.
+	Currencies []string
+	Aliases    SymbolAliases
+	Case       string // "upper" (default) or "lower" for {symbol} and {quote}
+
+	// PricePath is a dot separated path into the response; numeric segments
+	// index arrays, e.g. "data.0.price" or "{symbol}.{quote}".
+	PricePath  string
+	VolumePath string // optional 24h volume
+	Number     NumberFormat
+
+	// Success, when set, must hold for a 200 response to be accepted.
+	Success *RESTPredicate
+	// ErrorPath points to a vendor error message used in reported failures.
+	ErrorPath string
+}
+
+// NumberFormat tells how to read prices sent as strings. JSON numbers are
+// always accepted.
+type NumberFormat struct {
+	DecimalSeparator   string  // defaults to "."
+	ThousandsSeparator string  // stripped before parsing
+	Scale              float64 // multiplier, e.g. 0.01 for prices in cents
+}
+
+// RESTPredicate holds when the value at Path equals Equals. With an empty
+// Equals any value other than null, false, 0 or "" holds.
+type RESTPredicate struct {
+	Path   string
+	Equals string
+}
+
+// RESTProvider is a CryptoClient driven by a RESTSpec.
+type RESTProvider struct {
+	CryptoProvider
+	Spec RESTSpec
+}
+
+// NewRESTCryptoProvider validates spec and builds its client.
+func NewRESTCryptoProvider(client *http.Client, spec RESTSpec) (*RESTProvider, error) {
+	switch {
+	case spec.Name == "":
+		return nil, errors.New("rest vendor: name is required")
+	case spec.URL == "":
+		return nil, fmt.Errorf("rest vendor %s: url is required", spec.Name)
+	case spec.PricePath == "":
+		return nil, fmt.Errorf("rest vendor %s: price path is required", spec.Name)
+	case spec.Case != "" && spec.Case != "upper" && spec.Case != "lower":
+		return nil, fmt.Errorf("rest vendor %s: unknown case %q", spec.Name, spec.Case)
+	}
+	if len(spec.Currencies) == 0 {
+		spec.Currencies = []string{"USD"}
+	}
+
+	aliases := SymbolAliases{}
+	for k, v := range spec.Aliases {
+		aliases[strings.ToUpper(k)] = v
+	}
+	spec.Aliases = aliases
+
+	return &RESTProvider{
+		CryptoProvider: CryptoProvider{
+			BaseURL: spec.BaseURL,
+			Client:  client,
+		},
+		Spec: spec,
+	}, nil
+}
+
+func (c *RESTProvider) Name() string { return c.Spec.Name }
+
+func (c *RESTProvider) GetPrice(ctx context.Context, symbol string) (*models.Money, error) {
+	money := &models.Money{}
+	for _, currency := range c.Spec.Currencies {
+		price, volume, err := c.fetch(ctx, symbol, currency)
+		if err != nil {
+			return nil, err
+		}
+		money.Set(currency, price)
+		if volume > 0 {
+			money.Volume = volume
+		}
+	}
+	return money, nil
+}
+
+func (c *RESTProvider) fetch(ctx context.Context, symbol, currency string) (models.Decimal, float64, error) {
+	expand := c.expander(symbol, currency)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, expand(c.Spec.URL, url.PathEscape), nil)
+	if err != nil {
+		return models.Decimal{}, 0, err
+	}
+	for name, value := range c.Spec.Headers {
+		req.Header.Set(name, expand(value, nil))
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return models.Decimal{}, 0, transportError(c.Name(), err)
+	}
+	defer resp.Body.Close()
+
+	var doc any
+	dec := json.NewDecoder(resp.Body)
+	dec.UseNumber()
+	decodeErr := dec.Decode(&doc)
+
+	if resp.StatusCode != http.StatusOK {
+		return models.Decimal{}, 0, models.VendorError{
+			Vendor:     c.Name(),
+			StatusCode: resp.StatusCode,
+			Message:    c.errorMessage(doc, expand, fmt.Sprintf("%s api status %d", c.Name(), resp.StatusCode)),
+			Kind:       kindForStatus(resp.StatusCode),
+		}
+	}
+	if decodeErr != nil {
+		return models.Decimal{}, 0, models.VendorError{Vendor: c.Name(), StatusCode: resp.StatusCode, Message: decodeErr.Error(), Kind: models.ErrBadResponse}
+	}
+
+	if p := c.Spec.Success; p != nil {
+		value, _ := lookupPath(doc, expand(p.Path, nil))
+		if !p.holds(value) {
+			return models.Decimal{}, 0, models.VendorError{
+				Vendor:     c.Name(),
+				StatusCode: resp.StatusCode,
+				Message:    c.errorMessage(doc, expand, "success check failed on "+p.Path),
+				Kind:       models.ErrBadResponse,
+			}
+		}
+	}
+
+	pricePath := expand(c.Spec.PricePath, nil)
+	raw, ok := lookupPath(doc, pricePath)
+	if !ok {
+		// A well-formed answer without the field usually means an unknown pair.
+		return models.Decimal{}, 0, models.VendorError{
+			Vendor:     c.Name(),
+			StatusCode: resp.StatusCode,
+			Message:    fmt.Sprintf("no value at %s for %s/%s", pricePath, symbol, currency),
+			Kind:       models.ErrUnsupportedSymbol,
+		}
+	}
+	price, err := c.Spec.Number.parse(raw)
+	if err != nil {
+		return models.Decimal{}, 0, models.VendorError{Vendor: c.Name(), StatusCode: resp.StatusCode, Message: err.Error(), Kind: models.ErrBadResponse}
+	}
+
+	var volume float64
+	if c.Spec.VolumePath != "" {
+		if raw, ok := lookupPath(doc, expand(c.Spec.VolumePath, nil)); ok {
+			v, _ := c.Spec.Number.parse(raw)
+			volume = v.Float64()
+		}
+	}
+	return price, volume, nil
+}
+
+// expander substitutes the placeholders of one request. escape, when set, is
+// applied to symbol and quote values, and credentials are query escaped since
+// they usually travel as query parameters.
+func (c *RESTProvider) expander(symbol, currency string) func(string, func(string) string) string {
+	symbol, currency = c.Spec.Aliases.Resolve(symbol), c.Spec.Aliases.Resolve(currency)
+	if c.Spec.Case == "lower" {
+		symbol, currency = strings.ToLower(symbol), strings.ToLower(currency)
+	}
+
+	return func(template string, escape func(string) string) string {
+		s, q, key, secret := symbol, currency, c.Spec.Key, c.Spec.Secret
+		if escape != nil {
+			s, q = escape(s), escape(q)
+			key, secret = url.QueryEscape(key), url.QueryEscape(secret)
+		}
+		return strings.NewReplacer(
+			"{symbol}", s, "{quote}", q, "{base_url}", c.BaseURL,
+			"{key}", key, "{secret}", secret,
+		).Replace(template)
+	}
+}
+
+func (c *RESTProvider) errorMessage(doc any, expand func(string, func(string) string) string, fallback string) string {
+	if c.Spec.ErrorPath == "" {
+		return fallback
+	}
+	if v, ok := lookupPath(doc, expand(c.Spec.ErrorPath, nil)); ok && v != nil {
+		return fmt.Sprint(v)
+	}
+	return fallback
+}
+
+// lookupPath walks a decoded JSON document along a dot separated path.
+func lookupPath(doc any, path string) (any, bool) {
+	if path == "" {
+		return doc, true
+	}
+	current := doc
+	for _, segment := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]any:
+			v, ok := node[segment]
+			if !ok {
+				return nil, false
+			}
+			current = v
+		case []any:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			current = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func (p *RESTPredicate) holds(value any) bool {
+	if p.Equals != "" {
+		return value != nil && fmt.Sprint(value) == p.Equals
+	}
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case json.Number:
+		f, err := v.Float64()
+		return err == nil && f != 0
+	default:
+		return true
+	}
+}
+
+func (f NumberFormat) parse(value any) (models.Decimal, error) {
+	var (
+		v   models.Decimal
+		err error
+	)
+	switch raw := value.(type) {
+	case json.Number:
+		v, err = models.ParseDecimal(raw.String())
+	case float64:
+		v, err = models.NewDecimalFromFloat(raw)
+	case string:
+		s := strings.TrimSpace(raw)
+		if f.ThousandsSeparator != "" {
+			s = strings.ReplaceAll(s, f.ThousandsSeparator, "")
+		}
+		if f.DecimalSeparator != "" && f.DecimalSeparator != "." {
+			s = strings.Replace(s, f.DecimalSeparator, ".", 1)
+		}
+		v, err = models.ParseDecimal(s)
+	default:
+		return models.Decimal{}, fmt.Errorf("value %v is not a number", value)
+	}
+	if err != nil {
+		return models.Decimal{}, fmt.Errorf("invalid number %v: %w", value, err)
+	}
+	if f.Scale != 0 {
+		scale, err := models.NewDecimalFromFloat(f.Scale)
+		if err != nil {
+			return models.Decimal{}, fmt.Errorf("invalid scale %v: %w", f.Scale, err)
+		}
+		v = v.Mul(scale)
+	}
+	return v, nil
+}
@@ -0,0 +1,204 @@
+package repositories
+
+import (
+	"context"
+	"crypto-aggregator-service/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRESTProvider(t *testing.T, server *httptest.Server, spec RESTSpec) *RESTProvider {
+	t.Helper()
+	spec.BaseURL = server.URL
+	p, err := NewRESTCryptoProvider(server.Client(), spec)
+	require.NoError(t, err)
+	return p
+}
+
+func TestNewRESTCryptoProvider_Validates(t *testing.T) {
+	tests := map[string]RESTSpec{
+		"missing name": {URL: "http://x", PricePath: "price"},
+		"missing url":  {Name: "x", PricePath: "price"},
+		"missing path": {Name: "x", URL: "http://x"},
+		"unknown case": {Name: "x", URL: "http://x", PricePath: "price", Case: "title"},
+	}
+	for name, spec := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewRESTCryptoProvider(http.DefaultClient, spec)
+			assert.Error(t, err)
+		})
+	}
+
+	p, err := NewRESTCryptoProvider(http.DefaultClient, RESTSpec{Name: "internal", URL: "http://x", PricePath: "price"})
+	require.NoError(t, err)
+	assert.Equal(t, "internal", p.Name())
+	assert.Equal(t, []string{"USD"}, p.Spec.Currencies)
+}
+
+func TestRESTProvider_GetPrice_TemplatesAndPaths(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v3/simple/price", r.URL.Path)
+		assert.Equal(t, "bitcoin", r.URL.Query().Get("ids"))
+		assert.Equal(t, "secret", r.Header.Get("X-Api-Key"))
+
+		switch r.URL.Query().Get("vs_currencies") {
+		case "usd":
+			_, _ = w.Write([]byte(`{"bitcoin":{"usd":50000.5,"usd_24h_vol":1200}}`))
+		case "mxn":
+			_, _ = w.Write([]byte(`{"bitcoin":{"mxn":850000}}`))
+		default:
+			t.Errorf("unexpected quote %s", r.URL.RawQuery)
+		}
+	}))
+	defer server.Close()
+
+	p := newTestRESTProvider(t, server, RESTSpec{
+		Name:       "coingecko",
+		URL:        "{base_url}/api/v3/simple/price?ids={symbol}&vs_currencies={quote}",
+		Headers:    map[string]string{"X-Api-Key": "secret"},
+		Currencies: []string{"USD", "MXN"},
+		Aliases:    SymbolAliases{"btc": "bitcoin"},
+		Case:       "lower",
+		PricePath:  "{symbol}.{quote}",
+		VolumePath: "{symbol}.{quote}_24h_vol",
+	})
+
+	money, err := p.GetPrice(context.Background(), "BTC")
+
+	require.NoError(t, err)
+	assert.InDelta(t, 50000.5, money.USD.Float64(), 0.001)
+	assert.InDelta(t, 850000.0, money.MXN.Float64(), 0.001)
+	assert.InDelta(t, 1200.0, money.Volume, 0.001)
+}
+
+func TestRESTProvider_GetPrice_CredentialPlaceholders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer api-key", r.Header.Get("Authorization"))
+		assert.Equal(t, "s3cr&t", r.URL.Query().Get("signature"))
+		_, _ = w.Write([]byte(`{"price":"50000"}`))
+	}))
+	defer server.Close()
+
+	p := newTestRESTProvider(t, server, RESTSpec{
+		Name:      "internal",
+		URL:       "{base_url}/price/{symbol}?signature={secret}",
+		Headers:   map[string]string{"Authorization": "Bearer {key}"},
+		Key:       "api-key",
+		Secret:    "s3cr&t",
+		PricePath: "price",
+	})
+
+	money, err := p.GetPrice(context.Background(), "BTC")
+
+	require.NoError(t, err)
+	assert.InDelta(t, 50000.0, money.USD.Float64(), 0.001)
+}
+
+func TestRESTProvider_GetPrice_NumberFormat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":[{"price":"5.000.012,50"}]}`))
+	}))
+	defer server.Close()
+
+	p := newTestRESTProvider(t, server, RESTSpec{
+		Name:      "internal",
+		URL:       "{base_url}/quote/{symbol}",
+		PricePath: "data.0.price",
+		Number:    NumberFormat{DecimalSeparator: ",", ThousandsSeparator: ".", Scale: 0.01},
+	})
+
+	money, err := p.GetPrice(context.Background(), "BTC")
+
+	require.NoError(t, err)
+	assert.InDelta(t, 50000.125, money.USD.Float64(), 0.0001)
+}
+
+func TestRESTProvider_GetPrice_SuccessPredicate(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		success *RESTPredicate
+		wantErr bool
+	}{
+		{"equals holds", `{"status":"ok","price":1}`, &RESTPredicate{Path: "status", Equals: "ok"}, false},
+		{"equals fails", `{"status":"error","price":1,"message":"maintenance"}`, &RESTPredicate{Path: "status", Equals: "ok"}, true},
+		{"truthy holds", `{"success":true,"price":1}`, &RESTPredicate{Path: "success"}, false},
+		{"truthy fails", `{"success":false,"price":1}`, &RESTPredicate{Path: "success"}, true},
+		{"missing field", `{"price":1}`, &RESTPredicate{Path: "success"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			p := newTestRESTProvider(t, server, RESTSpec{
+				Name:      "internal",
+				URL:       "{base_url}/price",
+				PricePath: "price",
+				Success:   tt.success,
+				ErrorPath: "message",
+			})
+			_, err := p.GetPrice(context.Background(), "BTC")
+
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, models.ErrBadResponse)
+		})
+	}
+}
+
+func TestRESTProvider_GetPrice_MapsErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		kind   error
+	}{
+		{"missing price", http.StatusOK, `{"other":1}`, models.ErrUnsupportedSymbol},
+		{"not a number", http.StatusOK, `{"price":"n/a"}`, models.ErrBadResponse},
+		{"invalid json", http.StatusOK, `not json`, models.ErrBadResponse},
+		{"rate limited", http.StatusTooManyRequests, `{"error":"slow down"}`, models.ErrRateLimited},
+		{"unauthorized", http.StatusUnauthorized, `{}`, models.ErrUnauthorized},
+		{"server error", http.StatusBadGateway, ``, models.ErrProviderUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			p := newTestRESTProvider(t, server, RESTSpec{Name: "internal", URL: "{base_url}/price", PricePath: "price", ErrorPath: "error"})
+			_, err := p.GetPrice(context.Background(), "BTC")
+
+			assert.ErrorIs(t, err, tt.kind)
+		})
+	}
+}
+
+func TestRESTProvider_ErrorMessageFromPath(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":"slow down"}`))
+	}))
+	defer server.Close()
+
+	p := newTestRESTProvider(t, server, RESTSpec{Name: "internal", URL: "{base_url}/price", PricePath: "price", ErrorPath: "error"})
+	_, err := p.GetPrice(context.Background(), "BTC")
+
+	var vErr models.VendorError
+	require.ErrorAs(t, err, &vErr)
+	assert.Equal(t, "internal", vErr.Vendor)
+	assert.Equal(t, "slow down", vErr.Message)
+}
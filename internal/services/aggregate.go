@@ -0,0 +1,129 @@
+package services
+
+import (
+	"crypto-aggregator-service/internal/models"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// trimFraction is the share of quotes dropped from each end by trimmed_mean.
+const trimFraction = 0.2
+
+// quote is one vendor's answer for a component.
+type quote struct {
+	vendor    string
+	price     *models.Money
+	err       error
+	fetchedAt time.Time // when the answer arrived
+}
+
+// aggregate combines successful vendor quotes in each of currencies. Native
+// quotes are preferred; converted ones are used only when no vendor quoted a
+// currency natively, and the result is then flagged as derived.
+func aggregate(strategy models.AggregationStrategy, quotes []quote, currencies []string) (models.Money, error) {
+	var result models.Money
+
+	for _, currency := range currencies {
+		var native, derived []sample
+		for _, q := range quotes {
+			value, ok := q.price.Get(currency)
+			if !ok {
+				continue
+			}
+			s := sample{value: value, weight: q.price.Volume}
+			if q.price.IsDerived(currency) {
+				derived = append(derived, s)
+			} else {
+				native = append(native, s)
+			}
+		}
+
+		samples := native
+		if len(samples) == 0 {
+			samples = derived
+			if len(samples) == 0 {
+				continue
+			}
+			result.Derived = append(result.Derived, currency)
+		}
+
+		value, err := combine(strategy, samples)
+		if err != nil {
+			return models.Money{}, err
+		}
+		result.Set(currency, value)
+	}
+
+	for _, q := range quotes {
+		result.Volume += q.price.Volume
+		result.SetSourceTime(q.price.SourceTime)
+	}
+	return result, nil
+}
+
+type sample struct {
+	value  models.Decimal
+	weight float64
+}
+
+func combine(strategy models.AggregationStrategy, samples []sample) (models.Decimal, error) {
+	switch strategy {
+	case models.AggregationMedian:
+		return median(samples), nil
+	case models.AggregationTrimmedMean:
+		return trimmedMean(samples, trimFraction), nil
+	case models.AggregationVWAP:
+		return vwap(samples), nil
+	default:
+		return models.Decimal{}, fmt.Errorf("unsupported aggregation strategy %q", strategy)
+	}
+}
+
+func sortedValues(samples []sample) []models.Decimal {
+	values := make([]models.Decimal, len(samples))
+	for i, s := range samples {
+		values[i] = s.value
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].Cmp(values[j]) < 0 })
+	return values
+}
+
+func median(samples []sample) models.Decimal {
+	values := sortedValues(samples)
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return values[mid-1].Add(values[mid]).Quo(models.DecimalFromInt(2))
+	}
+	return values[mid]
+}
+
+func trimmedMean(samples []sample, fraction float64) models.Decimal {
+	values := sortedValues(samples)
+	trim := int(math.Floor(float64(len(values)) * fraction))
+	values = values[trim : len(values)-trim]
+
+	var sum models.Decimal
+	for _, v := range values {
+		sum = sum.Add(v)
+	}
+	return sum.Quo(models.DecimalFromInt(int64(len(values))))
+}
+
+// vwap weights every quote by its vendor volume. Vendors that do not report
+// volume are left out unless none does, in which case it is a plain mean.
+func vwap(samples []sample) models.Decimal {
+	var sum, weights models.Decimal
+	for _, s := range samples {
+		weight, err := models.NewDecimalFromFloat(s.weight)
+		if err == nil && weight.Sign() > 0 {
+			sum = sum.Add(s.value.Mul(weight))
+			weights = weights.Add(weight)
+		}
+	}
+	if weights.IsZero() {
+		return trimmedMean(samples, 0)
+	}
+	return sum.Quo(weights)
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"crypto-aggregator-service/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func quotesOf(prices ...models.Money) []quote {
+	quotes := make([]quote, len(prices))
+	for i := range prices {
+		quotes[i] = quote{vendor: "v", price: &prices[i]}
+	}
+	return quotes
+}
+
+func TestAggregate_Median(t *testing.T) {
+	result, err := aggregate(models.AggregationMedian, quotesOf(
+		models.Money{USD: models.DecimalFromFloat(100), MXN: models.DecimalFromFloat(1700)},
+		models.Money{USD: models.DecimalFromFloat(300), MXN: models.DecimalFromFloat(1800)},
+		models.Money{USD: models.DecimalFromFloat(110), MXN: models.DecimalFromFloat(1750)},
+	), models.DefaultCurrencies)
+
+	require.NoError(t, err)
+	assert.InDelta(t, 110.0, result.USD.Float64(), 0.001)
+	assert.InDelta(t, 1750.0, result.MXN.Float64(), 0.001)
+}
+
+func TestAggregate_MedianEvenCount(t *testing.T) {
+	result, err := aggregate(models.AggregationMedian, quotesOf(
+		models.Money{USD: models.DecimalFromFloat(100)},
+		models.Money{USD: models.DecimalFromFloat(200)},
+	), models.DefaultCurrencies)
+
+	require.NoError(t, err)
+	assert.InDelta(t, 150.0, result.USD.Float64(), 0.001)
+}
+
+func TestAggregate_TrimmedMeanDropsOutliers(t *testing.T) {
+	result, err := aggregate(models.AggregationTrimmedMean, quotesOf(
+		models.Money{USD: models.DecimalFromFloat(1)},
+		models.Money{USD: models.DecimalFromFloat(100)},
+		models.Money{USD: models.DecimalFromFloat(101)},
+		models.Money{USD: models.DecimalFromFloat(102)},
+		models.Money{USD: models.DecimalFromFloat(10000)},
+	), models.DefaultCurrencies)
+
+	require.NoError(t, err)
+	assert.InDelta(t, 101.0, result.USD.Float64(), 0.001)
+}
+
+func TestAggregate_TrimmedMeanSmallSampleIsMean(t *testing.T) {
+	result, err := aggregate(models.AggregationTrimmedMean, quotesOf(
+		models.Money{USD: models.DecimalFromFloat(100)},
+		models.Money{USD: models.DecimalFromFloat(200)},
+	), models.DefaultCurrencies)
+
+	require.NoError(t, err)
+	assert.InDelta(t, 150.0, result.USD.Float64(), 0.001)
+}
+
+func TestAggregate_VWAP(t *testing.T) {
+	result, err := aggregate(models.AggregationVWAP, quotesOf(
+		models.Money{USD: models.DecimalFromFloat(100), Volume: 3},
+		models.Money{USD: models.DecimalFromFloat(200), Volume: 1},
+		models.Money{USD: models.DecimalFromFloat(999)}, // no volume reported, ignored
+	), models.DefaultCurrencies)
+
+	require.NoError(t, err)
+	assert.InDelta(t, 125.0, result.USD.Float64(), 0.001)
+	assert.InDelta(t, 4.0, result.Volume, 0.001)
+}
+
+func TestAggregate_VWAPWithoutVolumesIsMean(t *testing.T) {
+	result, err := aggregate(models.AggregationVWAP, quotesOf(
+		models.Money{USD: models.DecimalFromFloat(100)},
+		models.Money{USD: models.DecimalFromFloat(200)},
+	), models.DefaultCurrencies)
+
+	require.NoError(t, err)
+	assert.InDelta(t, 150.0, result.USD.Float64(), 0.001)
+}
+
+func TestAggregate_PrefersNativeOverDerived(t *testing.T) {
+	result, err := aggregate(models.AggregationMedian, quotesOf(
+		models.Money{USD: models.DecimalFromFloat(100), MXN: models.DecimalFromFloat(1700)},
+		models.Money{USD: models.DecimalFromFloat(100), MXN: models.DecimalFromFloat(2000), Derived: []string{"MXN"}},
+	), models.DefaultCurrencies)
+
+	require.NoError(t, err)
+	assert.InDelta(t, 1700.0, result.MXN.Float64(), 0.001)
+	assert.Empty(t, result.Derived)
+}
+
+func TestAggregate_OnlyDerivedIsFlagged(t *testing.T) {
+	result, err := aggregate(models.AggregationMedian, quotesOf(
+		models.Money{USD: models.DecimalFromFloat(100), MXN: models.DecimalFromFloat(1700), Derived: []string{"MXN"}},
+		models.Money{USD: models.DecimalFromFloat(110), MXN: models.DecimalFromFloat(1870), Derived: []string{"MXN"}},
+	), models.DefaultCurrencies)
+
+	require.NoError(t, err)
+	assert.InDelta(t, 1785.0, result.MXN.Float64(), 0.001)
+	assert.Equal(t, []string{"MXN"}, result.Derived)
+}
+
+func TestAggregate_UnknownStrategy(t *testing.T) {
+	_, err := aggregate("mode", quotesOf(models.Money{USD: models.DecimalFromFloat(1)}), models.DefaultCurrencies)
+	assert.Error(t, err)
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"crypto-aggregator-service/internal/repositories"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// AssetRefresher keeps an AssetRegistry in sync with a vendor listing.
+type AssetRefresher struct {
+	registry *repositories.AssetRegistry
+	source   repositories.AssetSource
+	logger   *zap.SugaredLogger
+}
+
+func NewAssetRefresher(registry *repositories.AssetRegistry, source repositories.AssetSource, l *zap.SugaredLogger) *AssetRefresher {
+	return &AssetRefresher{registry: registry, source: source, logger: l}
+}
+
+// Start refreshes the registry every interval until ctx is done. A failed
+// refresh keeps the assets already known.
+func (r *AssetRefresher) Start(ctx context.Context, interval time.Duration) {
+	r.logger.Info("Starting asset refresh", zap.String("vendor", r.source.Name()), zap.Duration("interval", interval))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.Refresh(ctx)
+		}
+	}
+}
+
+// Refresh runs a single refresh, logging its outcome.
+func (r *AssetRefresher) Refresh(ctx context.Context) {
+	if err := r.registry.Refresh(ctx, r.source); err != nil {
+		r.logger.Warn("Failed to refresh assets", zap.String("vendor", r.source.Name()), zap.Error(err))
+		return
+	}
+	r.logger.Debug("Assets refreshed", zap.String("vendor", r.source.Name()), zap.Int("assets", len(r.registry.Symbols())))
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"crypto-aggregator-service/internal/models"
+	"errors"
+	"fmt"
+)
+
+// fillMissing converts the currencies a vendor did not quote natively from
+// one it did, and flags them as derived. USD is the preferred source since
+// every FX rate goes through it. A currency without a rate is left empty and
+// reported, without stopping the others. Without an FX provider the money is
+// left untouched.
+func (p *Poller) fillMissing(ctx context.Context, money *models.Money, currencies []string) error {
+	if p.fx == nil {
+		return nil
+	}
+
+	native := money.Currencies()
+	if len(native) == 0 {
+		return nil
+	}
+	source := native[0]
+	sourcePrice, _ := money.Get(source)
+
+	var failures []error
+	for _, currency := range currencies {
+		if _, ok := money.Get(currency); ok {
+			continue
+		}
+		rate, err := p.fx.Rate(ctx, source, currency)
+		if err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", currency, err))
+			continue
+		}
+		money.Set(currency, sourcePrice.Mul(rate))
+		money.Derived = append(money.Derived, currency)
+	}
+	return errors.Join(failures...)
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var hedgedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "poller_hedged_requests_total",
+	Help: "Vendor requests started because the previous vendor of a hedged component was slow.",
+}, []string{"vendor", "outcome"}) // outcome: won | lost | failed
+
+// hedgeAnswer is the result of one vendor call of a hedged component.
+type hedgeAnswer struct {
+	quote
+	call int // position of the vendor in the chain
+}
+
+// hedge walks the fallback chain of c racing its vendors: the next vendor
+// starts as soon as every call in flight failed, or when HedgeDelay passed
+// without an answer. The first price wins and the calls still in flight are
+// cancelled. Failed calls are returned next to the winner; cancelled ones
+// are not.
+func (p *Poller) hedge(ctx context.Context, c *pending) []quote {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Buffered for every vendor so losers never block after we return
+	answers := make(chan hedgeAnswer, len(c.policy.Vendors))
+	// Calls not answered yet, by chain position -> started by the hedge timer
+	inFlight := make(map[int]bool)
+
+	launch := func(hedged bool) bool {
+		for c.next < len(c.policy.Vendors) {
+			vendorName := c.policy.Vendors[c.next]
+			c.next++
+
+			client := p.client(vendorName)
+			if client == nil {
+				p.logger.Warn("No client available for vendor", zap.String("vendor", vendorName))
+				continue
+			}
+			if hedged {
+				p.logger.Info("Hedging slow vendor",
+					zap.String("symbol", c.symbol),
+					zap.String("vendor", client.Name()))
+			}
+
+			call := c.next - 1
+			inFlight[call] = hedged
+			go func() {
+				price, err := client.GetPrice(ctx, c.symbol)
+				answers <- hedgeAnswer{quote: quote{vendor: client.Name(), price: price, err: err, fetchedAt: time.Now()}, call: call}
+			}()
+			return true
+		}
+		return false
+	}
+
+	var quotes []quote
+	if !launch(false) {
+		return nil
+	}
+
+	timer := time.NewTimer(c.policy.HedgeDelay)
+	defer timer.Stop()
+
+	for len(inFlight) > 0 {
+		select {
+		case <-timer.C:
+			if launch(true) {
+				timer.Reset(c.policy.HedgeDelay)
+			}
+
+		case a := <-answers:
+			hedged := inFlight[a.call]
+			delete(inFlight, a.call)
+			if a.err == nil {
+				if hedged {
+					hedgedRequests.WithLabelValues(a.vendor, "won").Inc()
+				}
+				for call, hedged := range inFlight {
+					if hedged {
+						hedgedRequests.WithLabelValues(p.client(c.policy.Vendors[call]).Name(), "lost").Inc()
+					}
+				}
+				return append(quotes, a.quote)
+			}
+
+			if hedged {
+				hedgedRequests.WithLabelValues(a.vendor, "failed").Inc()
+			}
+			quotes = append(quotes, a.quote)
+			if len(inFlight) == 0 && ctx.Err() == nil && launch(false) {
+				timer.Reset(c.policy.HedgeDelay)
+			}
+		}
+	}
+	return quotes
+}
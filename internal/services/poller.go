@@ -4,6 +4,9 @@ import (
 	"context"
 	"crypto-aggregator-service/internal/models"
 	"crypto-aggregator-service/internal/repositories"
+	"errors"
+	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -12,91 +15,552 @@ import (
 )
 
 type Poller struct {
-	Store     *repositories.LayoutStore
-	vendors   map[string]repositories.CryptoClient
-	vendorMap map[int]string // LOOKUP: ComponentID -> VendorName
-	logger    *zap.SugaredLogger
+	Store        *repositories.LayoutStore
+	vendors      map[string]repositories.CryptoClient
+	policies     map[int]models.SourcePolicy // LOOKUP: ComponentID -> Vendors
+	fx           repositories.FXProvider
+	scales       map[string]int32 // LOOKUP: Symbol -> decimal places kept
+	assets       *repositories.AssetRegistry
+	staleAfter   time.Duration
+	mockFallback bool
+	logger       *zap.SugaredLogger
+
+	mu       sync.RWMutex
+	streamed map[int]bool // ComponentIDs currently fed by a Streamer
+}
+
+// PollerOption configures optional Poller dependencies.
+type PollerOption func(*Poller)
+
+// WithFX fills the currencies a vendor does not quote natively using fx.
+func WithFX(fx repositories.FXProvider) PollerOption {
+	return func(p *Poller) { p.fx = fx }
+}
+
+// WithScales sets the decimal places kept per asset symbol. Assets without
+// an entry keep models.DefaultScale.
+func WithScales(scales map[string]int32) PollerOption {
+	return func(p *Poller) {
+		p.scales = make(map[string]int32, len(scales))
+		for symbol, scale := range scales {
+			p.scales[strings.ToUpper(symbol)] = scale
+		}
+	}
+}
+
+// WithAssets names models and picks their decimals from the asset registry.
+// Scales set with WithScales take precedence over the asset decimals.
+func WithAssets(assets *repositories.AssetRegistry) PollerOption {
+	return func(p *Poller) { p.assets = assets }
+}
+
+// WithStaleAfter flags prices older than d as stale in Layout, unless the
+// component sets its own max age. Zero never flags them.
+func WithStaleAfter(d time.Duration) PollerOption {
+	return func(p *Poller) { p.staleAfter = d }
+}
+
+// WithMockFallback serves unregistered vendors with the "mock" client.
+// Meant for local development only: it lets random prices reach clients.
+func WithMockFallback() PollerOption {
+	return func(p *Poller) { p.mockFallback = true }
+}
+
+func NewPoller(s *repositories.LayoutStore, v map[string]repositories.CryptoClient, policies map[int]models.SourcePolicy, l *zap.SugaredLogger, opts ...PollerOption) *Poller {
+	p := &Poller{Store: s, vendors: v, policies: policies, logger: l}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// target is a layout slot waiting for a price.
+type target struct {
+	index  int
+	symbol string
 }
 
-func NewPoller(s *repositories.LayoutStore, v map[string]repositories.CryptoClient, vendorMap map[int]string, l *zap.SugaredLogger) *Poller {
-	return &Poller{Store: s, vendors: v, vendorMap: vendorMap, logger: l}
+// pending is a component being refreshed in the current cycle.
+type pending struct {
+	symbol string
+	policy models.SourcePolicy
+	next   int // position of the next vendor to try in policy.Vendors
+	quotes []quote
 }
 
+// Validate reports every configured vendor that has no registered client.
+func (p *Poller) Validate() error {
+	var problems []string
+	ids := make([]int, 0, len(p.policies))
+	for id := range p.policies {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		for _, vendorName := range p.policies[id].Vendors {
+			if _, ok := p.vendors[vendorName]; !ok {
+				problems = append(problems, fmt.Sprintf("component %d: vendor %q is not registered", id, vendorName))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Start polls every component until ctx is done. Components refresh every
+// interval unless their policy sets its own RefreshInterval; components that
+// share an interval share a timer, so their vendors are still batched.
 func (p *Poller) Start(ctx context.Context, interval time.Duration) {
-	interval = time.Second * 5
-	p.logger.Info("Starting poller service", zap.Duration("interval", interval))
+	if interval <= 0 {
+		interval = defaultRefreshInterval
+	}
+
+	var wg sync.WaitGroup
+	for every, ids := range p.schedule(interval) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.run(ctx, every, ids)
+		}()
+	}
+	wg.Wait()
+}
+
+// defaultRefreshInterval is used when Start gets no interval.
+const defaultRefreshInterval = 10 * time.Second
+
+// schedule groups the layout component IDs by refresh interval.
+func (p *Poller) schedule(interval time.Duration) map[time.Duration]map[int]bool {
+	groups := make(map[time.Duration]map[int]bool)
+	for _, comp := range p.Store.GetLayout() {
+		every := interval
+		if policy := p.policies[comp.ID]; policy.RefreshInterval > 0 {
+			every = policy.RefreshInterval
+		}
+		if groups[every] == nil {
+			groups[every] = make(map[int]bool)
+		}
+		groups[every][comp.ID] = true
+	}
+	return groups
+}
+
+// MinInterval returns the shortest refresh interval Start(ctx, interval)
+// would schedule. Vendor caches must expire within it, or a cycle would be
+// served the previous cycle's prices.
+func (p *Poller) MinInterval(interval time.Duration) time.Duration {
+	if interval <= 0 {
+		interval = defaultRefreshInterval
+	}
+	shortest := interval
+	for every := range p.schedule(interval) {
+		shortest = min(shortest, every)
+	}
+	return shortest
+}
+
+// run refreshes ids right away and then on every tick of their timer. Each
+// cycle is bounded by the interval, so retries and rate limiter waits never
+// outlive it.
+func (p *Poller) run(ctx context.Context, interval time.Duration, ids map[int]bool) {
+	p.logger.Info("Starting poller service", zap.Duration("interval", interval), zap.Int("components", len(ids)))
 
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	cycle := func() {
+		cycleCtx, cancel := context.WithTimeout(ctx, interval)
+		defer cancel()
+		p.refreshOnly(cycleCtx, ids)
+	}
+
 	// Initial fetch immediately
-	p.refresh(ctx)
+	cycle()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			p.refresh(ctx)
+			cycle()
 		}
 	}
 }
 
+// refresh refreshes every component of the layout.
 func (p *Poller) refresh(ctx context.Context) {
+	p.refreshOnly(ctx, nil)
+}
+
+// refreshOnly asks every vendor the components in ids need (all of them when
+// ids is nil), grouped by vendor so each one is called once per cycle when it
+// supports batching. Components without an aggregation walk their vendor list
+// in order, moving to the next vendor only when the previous one failed,
+// unless they are hedged.
+func (p *Poller) refreshOnly(ctx context.Context, ids map[int]bool) {
 	layout := p.Store.GetLayout()
-	p.logger.Info("Refreshing layout", zap.Int("size", len(layout)))
-	var wg sync.WaitGroup
 
+	components := make(map[int]*pending)
 	for i, comp := range layout {
-		// 1. Lookup Vendor for this ID
-		vendorName, ok := p.vendorMap[comp.ID]
-		if !ok {
+		if (ids != nil && !ids[comp.ID]) || p.isStreamed(comp.ID) {
+			continue
+		}
+		// 1. Lookup Vendors for this ID
+		policy, ok := p.policies[comp.ID]
+		if !ok || len(policy.Vendors) == 0 {
 			p.logger.Warn("No vendor configured for component", zap.Int("id", comp.ID))
 			continue
 		}
+		components[i] = &pending{symbol: comp.Symbol(), policy: policy}
+	}
+	p.logger.Info("Refreshing layout", zap.Int("size", len(components)))
 
-		// 2. Lookup the actual Client (Bitso/Binance)
-		client, ok := p.vendors[vendorName]
-		if !ok {
-			// Fallback to mock or skip
-			client = p.vendors["mock"]
+	// Hedged components race their own vendors next to the batched rounds
+	var hedges sync.WaitGroup
+	round := make(map[int]*pending, len(components))
+	for index, c := range components {
+		if !c.policy.Hedged() {
+			round[index] = c
+			continue
 		}
+		hedges.Add(1)
+		go func() {
+			defer hedges.Done()
+			c.quotes = p.hedge(ctx, c)
+		}()
+	}
 
-		wg.Add(1)
-
-		go func(index int, c models.Component, vClient repositories.CryptoClient) {
-			defer wg.Done()
+	for len(round) > 0 {
+		results := p.fetch(ctx, p.plan(round))
 
-			// Logic to extract symbol from "crypto_btc"
-			parts := strings.Split(string(c.Component), "_")
-			symbol := "BTC"
-			if len(parts) > 1 {
-				symbol = strings.ToUpper(parts[1])
+		next := make(map[int]*pending)
+		for index, c := range round {
+			c.quotes = append(c.quotes, results[index]...)
+			if c.policy.Aggregation == models.AggregationNone && !anySucceeded(results[index]) && c.next < len(c.policy.Vendors) {
+				next[index] = c
 			}
+		}
+		round = next
+	}
+	hedges.Wait()
+
+	for index, c := range components {
+		if len(c.quotes) == 0 {
+			// None of its vendors resolved to a client, so nothing was asked
+			err := fmt.Errorf("%w: %s", models.ErrVendorNotRegistered, strings.Join(c.policy.Vendors, ", "))
+			p.logger.Error("Failed to price component, keeping last value",
+				zap.String("symbol", c.symbol),
+				zap.Error(err))
+			p.Store.UpdateStatus(index, func(s models.ComponentStatus) models.ComponentStatus { return s.Failed(err) })
+			continue
+		}
+		p.update(ctx, index, *c, c.quotes)
+	}
+}
 
-			price, err := vClient.GetPrice(ctx, symbol)
+// plan groups the next vendor call of every component by client.
+func (p *Poller) plan(components map[int]*pending) map[repositories.CryptoClient][]target {
+	groups := make(map[repositories.CryptoClient][]target)
+	for index, c := range components {
+		t := target{index: index, symbol: c.symbol}
 
-			// ... (Create model and update store) ...
+		for c.next < len(c.policy.Vendors) {
+			// 2. Lookup the actual Client (Bitso/Binance)
+			vendorName := c.policy.Vendors[c.next]
+			c.next++
 
-			model := models.Model{
-				Date:         time.Now(),
-				Name:         symbol, // Could map BTC -> Bitcoin here
-				TickerSymbol: models.Ticker(symbol),
+			client := p.client(vendorName)
+			if client == nil {
+				p.logger.Warn("No client available for vendor", zap.String("vendor", vendorName))
+				continue
+			}
+			if c.next > 1 && c.policy.Aggregation == models.AggregationNone {
+				p.logger.Info("Falling back to next vendor",
+					zap.String("symbol", c.symbol),
+					zap.String("vendor", vendorName))
 			}
 
-			if err != nil {
-				p.logger.Error("Failed to fetch price",
-					zap.String("symbol", symbol),
-					zap.String("vendor", client.Name()),
-					zap.Error(err))
-			} else {
-				model.Price = *price
+			groups[client] = append(groups[client], t)
+			if c.policy.Aggregation == models.AggregationNone {
+				break
 			}
+		}
+	}
+	return groups
+}
+
+// client resolves a vendor name. Unregistered vendors resolve to nil unless
+// mock fallback was explicitly enabled.
+func (p *Poller) client(vendorName string) repositories.CryptoClient {
+	client, ok := p.vendors[vendorName]
+	if !ok && p.mockFallback {
+		client = p.vendors["mock"]
+	}
+	return client
+}
+
+func anySucceeded(quotes []quote) bool {
+	for _, q := range quotes {
+		if q.err == nil {
+			return true
+		}
+	}
+	return false
+}
 
-			// Update State
-			p.Store.UpdateModel(index, model)
+// fetch runs every vendor group concurrently and collects the quotes per
+// layout index.
+func (p *Poller) fetch(ctx context.Context, groups map[repositories.CryptoClient][]target) map[int][]quote {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results = make(map[int][]quote)
+	)
+	collect := func(t target, q quote) {
+		mu.Lock()
+		defer mu.Unlock()
+		results[t.index] = append(results[t.index], q)
+	}
 
-		}(i, comp, client)
+	for client, targets := range groups {
+		if batch, ok := client.(repositories.BatchCryptoClient); ok {
+			wg.Add(1)
+			go func(vClient repositories.BatchCryptoClient, ts []target) {
+				defer wg.Done()
+				p.fetchBatch(ctx, vClient, ts, collect)
+			}(batch, targets)
+			continue
+		}
+
+		for _, t := range targets {
+			wg.Add(1)
+			go func(vClient repositories.CryptoClient, t target) {
+				defer wg.Done()
+				price, err := vClient.GetPrice(ctx, t.symbol)
+				collect(t, quote{vendor: vClient.Name(), price: price, err: err, fetchedAt: time.Now()})
+			}(client, t)
+		}
 	}
 	wg.Wait()
+	return results
+}
+
+// fetchBatch prices a whole vendor group with one GetPrices call and maps
+// partial failures back to their components.
+func (p *Poller) fetchBatch(ctx context.Context, client repositories.BatchCryptoClient, targets []target, collect func(target, quote)) {
+	symbols := make([]string, 0, len(targets))
+	seen := make(map[string]bool, len(targets))
+	for _, t := range targets {
+		if !seen[t.symbol] {
+			seen[t.symbol] = true
+			symbols = append(symbols, t.symbol)
+		}
+	}
+
+	prices, err := client.GetPrices(ctx, symbols)
+	fetchedAt := time.Now()
+
+	var symErrs models.SymbolErrors
+	partial := errors.As(err, &symErrs)
+
+	for _, t := range targets {
+		price, ok := prices[t.symbol]
+		var tErr error
+		switch {
+		case ok && price != nil:
+		case partial && symErrs[t.symbol] != nil:
+			tErr = symErrs[t.symbol]
+		case err != nil && !partial:
+			tErr = err
+		default:
+			tErr = fmt.Errorf("%s returned no price for %s", client.Name(), t.symbol)
+		}
+		collect(t, quote{vendor: client.Name(), price: price, err: tErr, fetchedAt: fetchedAt})
+	}
+}
+
+// update stores the combined quotes of one component.
+func (p *Poller) update(ctx context.Context, index int, c pending, quotes []quote) {
+	model := models.Model{
+		Date:         time.Now(),
+		Name:         c.symbol,
+		TickerSymbol: models.Ticker(c.symbol),
+		Decimals:     p.scale(c.symbol),
+	}
+	if asset, ok := p.lookupAsset(c.symbol); ok {
+		model.Name, model.Category, model.IconURL = asset.Name, asset.Category, asset.IconURL
+	}
+
+	price, used, err := p.combine(ctx, c, quotes)
+	if err != nil {
+		// Keep the last known good model rather than serving a zero price
+		if shortCircuited(err) {
+			p.logger.Warn("Every vendor circuit is open, keeping last value", zap.String("symbol", c.symbol))
+		} else {
+			p.logger.Error("Failed to price component, keeping last value",
+				zap.String("symbol", c.symbol),
+				zap.Error(err))
+		}
+		p.Store.UpdateStatus(index, func(s models.ComponentStatus) models.ComponentStatus { return s.Failed(err) })
+		return
+	}
+
+	model.Price = price.Round(model.Decimals)
+	model.Market = price.Market
+	model.Vendors, model.FetchedAt = provenance(used)
+	if !price.SourceTime.IsZero() {
+		sourceTime := price.SourceTime
+		model.SourceTime = &sourceTime
+	}
+
+	// Update State
+	p.Store.UpdateModel(index, model)
+	p.Store.UpdateStatus(index, func(s models.ComponentStatus) models.ComponentStatus { return s.Succeeded(model.Date) })
+}
+
+// scale returns the decimal places kept for symbol.
+func (p *Poller) scale(symbol string) int32 {
+	if scale, ok := p.scales[symbol]; ok {
+		return scale
+	}
+	if asset, ok := p.lookupAsset(symbol); ok {
+		return asset.Decimals
+	}
+	return models.DefaultScale
+}
+
+func (p *Poller) lookupAsset(symbol string) (models.Asset, bool) {
+	if p.assets == nil {
+		return models.Asset{}, false
+	}
+	return p.assets.Lookup(symbol)
+}
+
+// combine converts the successful quotes and merges them with the component
+// aggregation, returning the quotes the price was made of. It returns a
+// models.ProvidersError when every vendor failed.
+func (p *Poller) combine(ctx context.Context, c pending, quotes []quote) (models.Money, []quote, error) {
+	var (
+		succeeded []quote
+		failures  []error
+	)
+	for _, q := range quotes {
+		if q.err != nil {
+			p.logger.Warn("Failed to fetch price",
+				zap.String("symbol", c.symbol),
+				zap.String("vendor", q.vendor),
+				zap.Error(q.err))
+			failures = append(failures, q.err)
+			continue
+		}
+
+		price := *q.price
+		if fxErr := p.fillMissing(ctx, &price, c.policy.QuoteCurrencies()); fxErr != nil {
+			p.logger.Warn("Failed to convert price",
+				zap.String("symbol", c.symbol),
+				zap.String("vendor", q.vendor),
+				zap.Error(fxErr))
+		}
+		succeeded = append(succeeded, quote{vendor: q.vendor, price: &price, fetchedAt: q.fetchedAt})
+	}
+
+	switch {
+	case len(succeeded) == 0:
+		return models.Money{}, nil, models.ProvidersError{Ticker: c.symbol, Details: failures}
+	case c.policy.Aggregation == models.AggregationNone:
+		return succeeded[0].price.Only(c.policy.QuoteCurrencies()), succeeded[:1], nil
+	default:
+		price, err := aggregate(c.policy.Aggregation, succeeded, c.policy.QuoteCurrencies())
+		return price, succeeded, err
+	}
+}
+
+// provenance lists the vendors behind a price, alphabetically since quotes
+// arrive in completion order, and when the oldest of their answers arrived.
+func provenance(quotes []quote) ([]string, time.Time) {
+	var (
+		vendors   []string
+		fetchedAt time.Time
+	)
+	for _, q := range quotes {
+		vendors = append(vendors, q.vendor)
+		if fetchedAt.IsZero() || q.fetchedAt.Before(fetchedAt) {
+			fetchedAt = q.fetchedAt
+		}
+	}
+	sort.Strings(vendors)
+	return vendors, fetchedAt
+}
+
+// Layout returns the current layout with the stale flag of every priced
+// model computed at the time of the call.
+func (p *Poller) Layout() models.Layout {
+	layout := p.Store.GetLayout()
+	now := time.Now()
+	for i, comp := range layout {
+		model, ok := comp.Model.(models.Model)
+		if !ok {
+			continue
+		}
+		maxAge := p.staleAfter
+		if policy := p.policies[comp.ID]; policy.StaleAfter > 0 {
+			maxAge = policy.StaleAfter
+		}
+		model.Stale = maxAge > 0 && model.Age(now) > maxAge
+		layout[i].Model = model
+	}
+	return layout
+}
+
+// SetStreamed marks components as fed by a live stream so refresh skips them,
+// or hands them back to polling when on is false.
+func (p *Poller) SetStreamed(ids []int, on bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.streamed == nil {
+		p.streamed = make(map[int]bool)
+	}
+	for _, id := range ids {
+		if on {
+			p.streamed[id] = true
+		} else {
+			delete(p.streamed, id)
+		}
+	}
+}
+
+func (p *Poller) isStreamed(id int) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.streamed[id]
+}
+
+// Apply stores a price pushed by a streaming vendor, converting missing
+// currencies like a polled quote.
+func (p *Poller) Apply(ctx context.Context, index int, symbol, vendor string, price models.Money) {
+	policy := models.SourcePolicy{Vendors: []string{vendor}}
+	if layout := p.Store.GetLayout(); index < len(layout) {
+		policy.Currencies = p.policies[layout[index].ID].Currencies
+	}
+	c := pending{symbol: symbol, policy: policy}
+	p.update(ctx, index, c, []quote{{vendor: vendor, price: &price, fetchedAt: time.Now()}})
+}
+
+// shortCircuited reports whether no vendor was called because every circuit
+// breaker on the way was open.
+func shortCircuited(err error) bool {
+	var pErr models.ProvidersError
+	if !errors.As(err, &pErr) || len(pErr.Details) == 0 {
+		return false
+	}
+	for _, detail := range pErr.Details {
+		if !errors.Is(detail, models.ErrCircuitOpen) {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,912 @@
+package services
+
+import (
+	"context"
+	"crypto-aggregator-service/internal/adapters"
+	"crypto-aggregator-service/internal/models"
+	"crypto-aggregator-service/internal/repositories"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeClient answers GetPrice from a fixed table and counts calls.
+type fakeClient struct {
+	name   string
+	prices map[string]float64
+	err    error
+	delay  time.Duration // answer latency, cut short by ctx
+	at     time.Time     // vendor timestamp of every price
+
+	mu        sync.Mutex
+	calls     []string
+	cancelled int
+}
+
+func (f *fakeClient) Name() string { return f.name }
+
+func (f *fakeClient) GetPrice(ctx context.Context, symbol string) (*models.Money, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, symbol)
+	f.mu.Unlock()
+
+	if f.delay > 0 {
+		select {
+		case <-ctx.Done():
+			f.mu.Lock()
+			f.cancelled++
+			f.mu.Unlock()
+			return nil, ctx.Err()
+		case <-time.After(f.delay):
+		}
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	price, ok := f.prices[symbol]
+	if !ok {
+		return nil, models.ErrUnsupportedSymbol
+	}
+	return &models.Money{USD: models.DecimalFromFloat(price), MXN: models.DecimalFromFloat(price * 17), SourceTime: f.at}, nil
+}
+
+func (f *fakeClient) callCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.calls)
+}
+
+// fakeBatchClient adds GetPrices on top of fakeClient.
+type fakeBatchClient struct {
+	fakeClient
+
+	batchMu    sync.Mutex
+	batchCalls [][]string
+}
+
+func (f *fakeBatchClient) GetPrices(ctx context.Context, symbols []string) (map[string]*models.Money, error) {
+	f.batchMu.Lock()
+	f.batchCalls = append(f.batchCalls, symbols)
+	f.batchMu.Unlock()
+
+	if f.err != nil {
+		return nil, f.err
+	}
+	prices := map[string]*models.Money{}
+	failed := models.SymbolErrors{}
+	for _, symbol := range symbols {
+		price, ok := f.prices[symbol]
+		if !ok {
+			failed[symbol] = models.ErrUnsupportedSymbol
+			continue
+		}
+		prices[symbol] = &models.Money{USD: models.DecimalFromFloat(price), MXN: models.DecimalFromFloat(price * 17)}
+	}
+	if len(failed) > 0 {
+		return prices, failed
+	}
+	return prices, nil
+}
+
+// singleVendors builds one-vendor policies from ID -> vendor.
+func singleVendors(vendors map[int]string) map[int]models.SourcePolicy {
+	policies := make(map[int]models.SourcePolicy, len(vendors))
+	for id, vendor := range vendors {
+		policies[id] = models.SourcePolicy{Vendors: []string{vendor}}
+	}
+	return policies
+}
+
+func testLayout() models.Layout {
+	return models.Layout{
+		{ID: 1, Component: "crypto_btc"},
+		{ID: 2, Component: "crypto_eth"},
+		{ID: 3, Component: "crypto_xrp"},
+	}
+}
+
+func modelAt(t *testing.T, store *repositories.LayoutStore, index int) models.Model {
+	t.Helper()
+	model, ok := store.GetLayout()[index].Model.(models.Model)
+	require.True(t, ok, "component %d has no model", index)
+	return model
+}
+
+func statusAt(t *testing.T, store *repositories.LayoutStore, index int) models.ComponentStatus {
+	t.Helper()
+	status := store.GetLayout()[index].Status
+	require.NotNil(t, status, "component %d has no status", index)
+	return *status
+}
+
+func TestPoller_Refresh_PerSymbolClient(t *testing.T) {
+	client := &fakeClient{name: "single", prices: map[string]float64{"BTC": 50000, "ETH": 3000, "XRP": 0.5}}
+	store := repositories.NewLayoutStore(testLayout())
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"single": client},
+		singleVendors(map[int]string{1: "single", 2: "single", 3: "single"}),
+		zap.NewNop().Sugar())
+
+	poller.refresh(context.Background())
+
+	assert.Equal(t, 3, client.callCount())
+	assert.InDelta(t, 50000.0, modelAt(t, store, 0).Price.USD.Float64(), 0.001)
+	assert.InDelta(t, 3000.0, modelAt(t, store, 1).Price.USD.Float64(), 0.001)
+	assert.InDelta(t, 0.5, modelAt(t, store, 2).Price.USD.Float64(), 0.001)
+}
+
+func TestPoller_Refresh_BatchClientCalledOncePerCycle(t *testing.T) {
+	client := &fakeBatchClient{fakeClient: fakeClient{name: "batch", prices: map[string]float64{"BTC": 50000, "ETH": 3000, "XRP": 0.5}}}
+	store := repositories.NewLayoutStore(testLayout())
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"batch": client},
+		singleVendors(map[int]string{1: "batch", 2: "batch", 3: "batch"}),
+		zap.NewNop().Sugar())
+
+	poller.refresh(context.Background())
+
+	require.Len(t, client.batchCalls, 1)
+	assert.ElementsMatch(t, []string{"BTC", "ETH", "XRP"}, client.batchCalls[0])
+	assert.Zero(t, client.callCount())
+	assert.Equal(t, models.Ticker("ETH"), modelAt(t, store, 1).TickerSymbol)
+	assert.InDelta(t, 3000.0, modelAt(t, store, 1).Price.USD.Float64(), 0.001)
+}
+
+func TestPoller_Refresh_GroupsByVendor(t *testing.T) {
+	batch := &fakeBatchClient{fakeClient: fakeClient{name: "batch", prices: map[string]float64{"BTC": 50000, "XRP": 0.5}}}
+	single := &fakeClient{name: "single", prices: map[string]float64{"ETH": 3000}}
+	store := repositories.NewLayoutStore(testLayout())
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"batch": batch, "single": single},
+		singleVendors(map[int]string{1: "batch", 2: "single", 3: "batch"}),
+		zap.NewNop().Sugar())
+
+	poller.refresh(context.Background())
+
+	require.Len(t, batch.batchCalls, 1)
+	assert.ElementsMatch(t, []string{"BTC", "XRP"}, batch.batchCalls[0])
+	assert.Equal(t, []string{"ETH"}, single.calls)
+	assert.InDelta(t, 3000.0, modelAt(t, store, 1).Price.USD.Float64(), 0.001)
+}
+
+func TestPoller_Refresh_BatchPartialFailure(t *testing.T) {
+	client := &fakeBatchClient{fakeClient: fakeClient{name: "batch", prices: map[string]float64{"BTC": 50000, "XRP": 0.5}}}
+	store := repositories.NewLayoutStore(testLayout())
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"batch": client},
+		singleVendors(map[int]string{1: "batch", 2: "batch", 3: "batch"}),
+		zap.NewNop().Sugar())
+
+	poller.refresh(context.Background())
+
+	assert.InDelta(t, 50000.0, modelAt(t, store, 0).Price.USD.Float64(), 0.001)
+	assert.Nil(t, store.GetLayout()[1].Model, "a failed symbol never gets a zero price")
+	assert.Equal(t, models.StateError, statusAt(t, store, 1).State)
+	assert.InDelta(t, 0.5, modelAt(t, store, 2).Price.USD.Float64(), 0.001)
+}
+
+func TestPoller_Refresh_BatchTotalFailure(t *testing.T) {
+	client := &fakeBatchClient{fakeClient: fakeClient{name: "batch", err: errors.New("down")}}
+	store := repositories.NewLayoutStore(testLayout())
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"batch": client},
+		singleVendors(map[int]string{1: "batch", 2: "batch", 3: "batch"}),
+		zap.NewNop().Sugar())
+
+	poller.refresh(context.Background())
+
+	for i := range 3 {
+		assert.Nil(t, store.GetLayout()[i].Model)
+		assert.Equal(t, models.StateError, statusAt(t, store, i).State)
+	}
+}
+
+func TestPoller_Refresh_UnknownVendorFallsBackToMockWhenEnabled(t *testing.T) {
+	mock := &fakeClient{name: "mock", prices: map[string]float64{"BTC": 1}}
+	store := repositories.NewLayoutStore(testLayout()[:1])
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"mock": mock},
+		singleVendors(map[int]string{1: "missing"}),
+		zap.NewNop().Sugar(),
+		WithMockFallback())
+
+	poller.refresh(context.Background())
+
+	assert.Equal(t, 1, mock.callCount())
+}
+
+func TestPoller_Refresh_UnknownVendorNeverUsesMockByDefault(t *testing.T) {
+	mock := &fakeClient{name: "mock", prices: map[string]float64{"BTC": 1}}
+	store := repositories.NewLayoutStore(testLayout()[:1])
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"mock": mock},
+		singleVendors(map[int]string{1: "missing"}),
+		zap.NewNop().Sugar())
+
+	poller.refresh(context.Background())
+
+	assert.Zero(t, mock.callCount())
+	assert.Nil(t, store.GetLayout()[0].Model)
+	status := store.GetLayout()[0].Status
+	require.NotNil(t, status)
+	assert.Equal(t, models.StateError, status.State)
+	assert.Equal(t, `vendor not registered: missing`, status.LastError)
+}
+
+func TestPoller_Refresh_SkipsComponentsWithoutVendor(t *testing.T) {
+	store := repositories.NewLayoutStore(testLayout())
+	poller := NewPoller(store, nil, singleVendors(map[int]string{}), zap.NewNop().Sugar())
+
+	poller.refresh(context.Background())
+
+	for _, comp := range store.GetLayout() {
+		assert.Nil(t, comp.Model)
+	}
+}
+
+func TestPoller_Refresh_ConvertsMissingCurrencyWithFX(t *testing.T) {
+	client := &usdOnlyClient{price: 50000}
+	store := repositories.NewLayoutStore(testLayout()[:1])
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"usd": client},
+		singleVendors(map[int]string{1: "usd"}),
+		zap.NewNop().Sugar(),
+		WithFX(repositories.NewStaticFXProvider(map[string]float64{"MXN": 17})))
+
+	poller.refresh(context.Background())
+
+	model := modelAt(t, store, 0)
+	assert.InDelta(t, 50000.0, model.Price.USD.Float64(), 0.001)
+	assert.InDelta(t, 850000.0, model.Price.MXN.Float64(), 0.001)
+	assert.Equal(t, []string{"MXN"}, model.Price.Derived)
+}
+
+func TestPoller_Refresh_ComponentCurrencies(t *testing.T) {
+	client := &fakeClient{name: "a", prices: map[string]float64{"BTC": 100}} // quotes USD and MXN
+	store := repositories.NewLayoutStore(testLayout()[:1])
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"a": client},
+		map[int]models.SourcePolicy{1: {Vendors: []string{"a"}, Currencies: []string{"BRL", "USD"}}},
+		zap.NewNop().Sugar(),
+		WithFX(repositories.NewStaticFXProvider(map[string]float64{"BRL": 5, "MXN": 17})))
+
+	poller.refresh(context.Background())
+
+	price := modelAt(t, store, 0).Price
+	assert.Equal(t, []string{"USD", "BRL"}, price.Currencies(), "MXN was not requested")
+	assert.InDelta(t, 500.0, price.Quotes["BRL"].Float64(), 0.001)
+	assert.Equal(t, []string{"BRL"}, price.Derived)
+}
+
+func TestPoller_Refresh_MissingRateSkipsOnlyThatCurrency(t *testing.T) {
+	client := &usdOnlyClient{price: 100}
+	store := repositories.NewLayoutStore(testLayout()[:1])
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"usd": client},
+		map[int]models.SourcePolicy{1: {Vendors: []string{"usd"}, Currencies: []string{"USD", "MXN", "EUR", "BRL"}}},
+		zap.NewNop().Sugar(),
+		WithFX(repositories.NewStaticFXProvider(map[string]float64{"MXN": 17, "BRL": 5})))
+
+	poller.refresh(context.Background())
+
+	price := modelAt(t, store, 0).Price
+	assert.Equal(t, []string{"USD", "MXN", "BRL"}, price.Currencies(), "EUR has no rate")
+	assert.InDelta(t, 500.0, price.Quotes["BRL"].Float64(), 0.001)
+	assert.Equal(t, []string{"MXN", "BRL"}, price.Derived)
+}
+
+func TestPoller_Refresh_AggregatesComponentCurrencies(t *testing.T) {
+	a := &fakeClient{name: "a", prices: map[string]float64{"BTC": 100}}
+	b := &fakeClient{name: "b", prices: map[string]float64{"BTC": 110}}
+	store := repositories.NewLayoutStore(testLayout()[:1])
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"a": a, "b": b},
+		map[int]models.SourcePolicy{1: {Vendors: []string{"a", "b"}, Aggregation: models.AggregationMedian, Currencies: []string{"EUR"}}},
+		zap.NewNop().Sugar(),
+		WithFX(repositories.NewStaticFXProvider(map[string]float64{"EUR": 0.5, "MXN": 17})))
+
+	poller.refresh(context.Background())
+
+	price := modelAt(t, store, 0).Price
+	assert.Equal(t, []string{"EUR"}, price.Currencies())
+	assert.InDelta(t, 52.5, price.Quotes["EUR"].Float64(), 0.001)
+	assert.Equal(t, []string{"EUR"}, price.Derived)
+}
+
+func TestPoller_Refresh_RoundsToAssetScale(t *testing.T) {
+	a := &fakeClient{name: "a", prices: map[string]float64{"BTC": 65012.34, "XRP": 0.52341}}
+	b := &fakeClient{name: "b", prices: map[string]float64{"BTC": 65012.35, "XRP": 0.52344}}
+	store := repositories.NewLayoutStore(models.Layout{{ID: 1, Component: "crypto_btc"}, {ID: 3, Component: "crypto_xrp"}})
+	median := models.SourcePolicy{Vendors: []string{"a", "b"}, Aggregation: models.AggregationMedian, Currencies: []string{"USD"}}
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"a": a, "b": b},
+		map[int]models.SourcePolicy{1: median, 3: median},
+		zap.NewNop().Sugar(),
+		WithScales(map[string]int32{"btc": 2}))
+
+	poller.refresh(context.Background())
+
+	// (65012.34 + 65012.35) / 2 = 65012.345 rounds half up at 2 places
+	assert.Equal(t, "65012.35", modelAt(t, store, 0).Price.USD.String())
+	// XRP keeps the default 8 places: (0.52341 + 0.52344) / 2 = 0.523425
+	assert.Equal(t, "0.523425", modelAt(t, store, 1).Price.USD.String())
+}
+
+func TestPoller_Refresh_EnrichesModelFromAssets(t *testing.T) {
+	client := &fakeClient{name: "single", prices: map[string]float64{"BTC": 65012.345, "ETH": 3000.123456789}}
+	store := repositories.NewLayoutStore(testLayout()[:2])
+	assets := repositories.NewAssetRegistry([]models.Asset{
+		{Symbol: "BTC", Name: "Bitcoin", Decimals: 2, Category: "layer1", IconURL: "https://example.com/btc.png"},
+		{Symbol: "ETH", Name: "Ethereum", Decimals: 2},
+	})
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"single": client},
+		singleVendors(map[int]string{1: "single", 2: "single"}),
+		zap.NewNop().Sugar(),
+		WithAssets(assets),
+		WithScales(map[string]int32{"ETH": 4}))
+
+	poller.refresh(context.Background())
+
+	btc := modelAt(t, store, 0)
+	assert.Equal(t, "Bitcoin", btc.Name)
+	assert.Equal(t, models.Ticker("BTC"), btc.TickerSymbol)
+	assert.Equal(t, "layer1", btc.Category)
+	assert.Equal(t, "https://example.com/btc.png", btc.IconURL)
+	assert.Equal(t, int32(2), btc.Decimals)
+	assert.Equal(t, "65012.35", btc.Price.USD.String())
+
+	eth := modelAt(t, store, 1)
+	assert.Equal(t, "Ethereum", eth.Name)
+	assert.Equal(t, int32(4), eth.Decimals, "configured scales override asset decimals")
+	assert.Equal(t, "3000.1235", eth.Price.USD.String())
+}
+
+func TestPoller_Refresh_WithoutFXLeavesCurrencyEmpty(t *testing.T) {
+	client := &usdOnlyClient{price: 50000}
+	store := repositories.NewLayoutStore(testLayout()[:1])
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"usd": client},
+		singleVendors(map[int]string{1: "usd"}),
+		zap.NewNop().Sugar())
+
+	poller.refresh(context.Background())
+
+	model := modelAt(t, store, 0)
+	assert.Zero(t, model.Price.MXN.Float64())
+	assert.Empty(t, model.Price.Derived)
+}
+
+func TestPoller_Refresh_NativeQuotesAreNotDerived(t *testing.T) {
+	client := &fakeClient{name: "both", prices: map[string]float64{"BTC": 50000}}
+	store := repositories.NewLayoutStore(testLayout()[:1])
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"both": client},
+		singleVendors(map[int]string{1: "both"}),
+		zap.NewNop().Sugar(),
+		WithFX(repositories.NewStaticFXProvider(map[string]float64{"MXN": 1})))
+
+	poller.refresh(context.Background())
+
+	model := modelAt(t, store, 0)
+	assert.InDelta(t, 850000.0, model.Price.MXN.Float64(), 0.001)
+	assert.Empty(t, model.Price.Derived)
+}
+
+// usdOnlyClient mimics vendors without MXN books.
+type usdOnlyClient struct{ price float64 }
+
+func (c *usdOnlyClient) Name() string { return "usd" }
+
+func (c *usdOnlyClient) GetPrice(ctx context.Context, symbol string) (*models.Money, error) {
+	return &models.Money{USD: models.DecimalFromFloat(c.price)}, nil
+}
+
+func TestPoller_Refresh_AggregatesVendorsConcurrently(t *testing.T) {
+	a := &fakeClient{name: "a", prices: map[string]float64{"BTC": 100}}
+	b := &fakeBatchClient{fakeClient: fakeClient{name: "b", prices: map[string]float64{"BTC": 300}}}
+	c := &fakeClient{name: "c", prices: map[string]float64{"BTC": 110}}
+	store := repositories.NewLayoutStore(testLayout()[:1])
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"a": a, "b": b, "c": c},
+		map[int]models.SourcePolicy{1: {Vendors: []string{"a", "b", "c"}, Aggregation: models.AggregationMedian}},
+		zap.NewNop().Sugar())
+
+	poller.refresh(context.Background())
+
+	assert.Equal(t, 1, a.callCount())
+	assert.Len(t, b.batchCalls, 1)
+	assert.Equal(t, 1, c.callCount())
+	assert.InDelta(t, 110.0, modelAt(t, store, 0).Price.USD.Float64(), 0.001)
+}
+
+func TestPoller_Refresh_AggregationIgnoresFailedVendors(t *testing.T) {
+	ok := &fakeClient{name: "ok", prices: map[string]float64{"BTC": 100}}
+	down := &fakeClient{name: "down", err: errors.New("down")}
+	store := repositories.NewLayoutStore(testLayout()[:1])
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"ok": ok, "down": down},
+		map[int]models.SourcePolicy{1: {Vendors: []string{"down", "ok"}, Aggregation: models.AggregationTrimmedMean}},
+		zap.NewNop().Sugar())
+
+	poller.refresh(context.Background())
+
+	assert.InDelta(t, 100.0, modelAt(t, store, 0).Price.USD.Float64(), 0.001)
+}
+
+func TestPoller_Refresh_WithoutAggregationUsesFirstVendorOnly(t *testing.T) {
+	first := &fakeClient{name: "first", prices: map[string]float64{"BTC": 100}}
+	second := &fakeClient{name: "second", prices: map[string]float64{"BTC": 200}}
+	store := repositories.NewLayoutStore(testLayout()[:1])
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"first": first, "second": second},
+		map[int]models.SourcePolicy{1: {Vendors: []string{"first", "second"}}},
+		zap.NewNop().Sugar())
+
+	poller.refresh(context.Background())
+
+	assert.Zero(t, second.callCount())
+	assert.InDelta(t, 100.0, modelAt(t, store, 0).Price.USD.Float64(), 0.001)
+}
+
+func TestPoller_Refresh_RecordsProvenance(t *testing.T) {
+	at := time.Date(2026, 10, 18, 15, 4, 5, 0, time.UTC)
+	client := &fakeClient{name: "single", prices: map[string]float64{"BTC": 100}, at: at}
+	store := repositories.NewLayoutStore(testLayout()[:1])
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"single": client},
+		singleVendors(map[int]string{1: "single"}),
+		zap.NewNop().Sugar())
+
+	before := time.Now()
+	poller.refresh(context.Background())
+
+	model := modelAt(t, store, 0)
+	assert.Equal(t, []string{"single"}, model.Vendors)
+	require.NotNil(t, model.SourceTime)
+	assert.Equal(t, at, *model.SourceTime)
+	assert.False(t, model.FetchedAt.Before(before))
+}
+
+func TestPoller_Refresh_AggregatedProvenanceKeepsOldestTime(t *testing.T) {
+	older := time.Date(2026, 10, 18, 15, 4, 0, 0, time.UTC)
+	a := &fakeClient{name: "a", prices: map[string]float64{"BTC": 100}, at: older.Add(5 * time.Second)}
+	b := &fakeClient{name: "b", prices: map[string]float64{"BTC": 110}, at: older}
+	down := &fakeClient{name: "down", err: errors.New("down")}
+	store := repositories.NewLayoutStore(testLayout()[:1])
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"a": a, "b": b, "down": down},
+		map[int]models.SourcePolicy{1: {Vendors: []string{"a", "down", "b"}, Aggregation: models.AggregationMedian}},
+		zap.NewNop().Sugar())
+
+	poller.refresh(context.Background())
+
+	model := modelAt(t, store, 0)
+	assert.Equal(t, []string{"a", "b"}, model.Vendors)
+	require.NotNil(t, model.SourceTime)
+	assert.Equal(t, older, *model.SourceTime)
+}
+
+func TestPoller_Refresh_WithoutVendorTimeLeavesSourceTimeNil(t *testing.T) {
+	store := repositories.NewLayoutStore(testLayout()[:1])
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"usd": &usdOnlyClient{price: 100}},
+		singleVendors(map[int]string{1: "usd"}),
+		zap.NewNop().Sugar())
+
+	poller.refresh(context.Background())
+
+	model := modelAt(t, store, 0)
+	assert.Nil(t, model.SourceTime)
+	assert.False(t, model.FetchedAt.IsZero())
+}
+
+func TestPoller_Layout_FlagsStalePrices(t *testing.T) {
+	old := time.Now().Add(-time.Minute)
+	fresh := time.Now()
+	layout := models.Layout{
+		{ID: 1, Component: "crypto_btc", Model: models.Model{SourceTime: &old}},
+		{ID: 2, Component: "crypto_eth", Model: models.Model{FetchedAt: old}},
+		{ID: 3, Component: "crypto_xrp", Model: models.Model{SourceTime: &fresh}},
+		{ID: 4, Component: "crypto_sol", Model: models.Model{FetchedAt: old}},
+	}
+	poller := NewPoller(repositories.NewLayoutStore(layout), nil,
+		map[int]models.SourcePolicy{4: {StaleAfter: 2 * time.Minute}},
+		zap.NewNop().Sugar(),
+		WithStaleAfter(30*time.Second))
+
+	got := poller.Layout()
+
+	stale := make([]bool, len(got))
+	for i, comp := range got {
+		stale[i] = comp.Model.(models.Model).Stale
+	}
+	assert.Equal(t, []bool{true, true, false, false}, stale)
+}
+
+func TestPoller_Layout_NeverStaleWithoutMaxAge(t *testing.T) {
+	old := time.Now().Add(-time.Hour)
+	poller := NewPoller(repositories.NewLayoutStore(models.Layout{
+		{ID: 1, Component: "crypto_btc", Model: models.Model{SourceTime: &old}},
+	}), nil, nil, zap.NewNop().Sugar())
+
+	assert.False(t, poller.Layout()[0].Model.(models.Model).Stale)
+}
+
+func TestPoller_Schedule_GroupsComponentsByInterval(t *testing.T) {
+	poller := NewPoller(repositories.NewLayoutStore(testLayout()), nil,
+		map[int]models.SourcePolicy{
+			1: {Vendors: []string{"a"}, RefreshInterval: 2 * time.Second},
+			2: {Vendors: []string{"a"}},
+			3: {Vendors: []string{"a"}, RefreshInterval: time.Minute},
+		},
+		zap.NewNop().Sugar())
+
+	assert.Equal(t, map[time.Duration]map[int]bool{
+		2 * time.Second:  {1: true},
+		10 * time.Second: {2: true},
+		time.Minute:      {3: true},
+	}, poller.schedule(10*time.Second))
+}
+
+func TestPoller_MinInterval(t *testing.T) {
+	poller := NewPoller(repositories.NewLayoutStore(testLayout()), nil,
+		map[int]models.SourcePolicy{
+			1: {Vendors: []string{"a"}, RefreshInterval: 2 * time.Second},
+			3: {Vendors: []string{"a"}, RefreshInterval: time.Minute},
+		},
+		zap.NewNop().Sugar())
+
+	assert.Equal(t, 2*time.Second, poller.MinInterval(10*time.Second))
+	assert.Equal(t, time.Second, poller.MinInterval(time.Second))
+	assert.Equal(t, 2*time.Second, poller.MinInterval(0), "falls back to the default interval")
+}
+
+func TestPoller_Start_RefreshesEachComponentOnItsOwnTimer(t *testing.T) {
+	client := &fakeClient{name: "single", prices: map[string]float64{"BTC": 100, "ETH": 10}}
+	store := repositories.NewLayoutStore(testLayout()[:2])
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"single": client},
+		map[int]models.SourcePolicy{
+			1: {Vendors: []string{"single"}, RefreshInterval: 10 * time.Millisecond},
+			2: {Vendors: []string{"single"}},
+		},
+		zap.NewNop().Sugar())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		poller.Start(ctx, time.Hour)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool {
+		client.mu.Lock()
+		defer client.mu.Unlock()
+		btc := 0
+		for _, symbol := range client.calls {
+			if symbol == "BTC" {
+				btc++
+			}
+		}
+		return btc >= 3
+	}, 2*time.Second, 5*time.Millisecond)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Start did not return after cancel")
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	eth := 0
+	for _, symbol := range client.calls {
+		if symbol == "ETH" {
+			eth++
+		}
+	}
+	assert.Equal(t, 1, eth, "the default interval only fired the initial refresh")
+}
+
+func TestPoller_Start_CycleNeverOutlivesItsInterval(t *testing.T) {
+	client := &fakeClient{name: "slow", prices: map[string]float64{"BTC": 100}, delay: time.Hour}
+	store := repositories.NewLayoutStore(testLayout()[:1])
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"slow": client},
+		singleVendors(map[int]string{1: "slow"}),
+		zap.NewNop().Sugar())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go poller.Start(ctx, 20*time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		client.mu.Lock()
+		defer client.mu.Unlock()
+		return client.cancelled >= 2
+	}, 2*time.Second, 5*time.Millisecond, "each cycle cancels the vendor call at its deadline")
+	require.Eventually(t, func() bool {
+		status := store.GetLayout()[0].Status
+		return status != nil && strings.Contains(status.LastError, context.DeadlineExceeded.Error())
+	}, 2*time.Second, 5*time.Millisecond)
+}
+
+func TestPoller_Combine_AllVendorsFailReturnsProvidersError(t *testing.T) {
+	poller := NewPoller(repositories.NewLayoutStore(nil), nil, nil, zap.NewNop().Sugar())
+	errA, errB := errors.New("a down"), errors.New("b down")
+
+	_, _, err := poller.combine(context.Background(),
+		pending{symbol: "BTC", policy: models.SourcePolicy{Vendors: []string{"a", "b"}, Aggregation: models.AggregationMedian}},
+		[]quote{{vendor: "a", err: errA}, {vendor: "b", err: errB}})
+
+	var providersErr models.ProvidersError
+	require.ErrorAs(t, err, &providersErr)
+	assert.Equal(t, "BTC", providersErr.Ticker)
+	assert.Equal(t, []error{errA, errB}, providersErr.Details)
+}
+
+func TestPoller_Refresh_FallbackChainTriesNextVendorOnFailure(t *testing.T) {
+	primary := &fakeBatchClient{fakeClient: fakeClient{name: "primary", prices: map[string]float64{"BTC": 100}}}
+	secondary := &fakeClient{name: "secondary", prices: map[string]float64{"ETH": 3000, "BTC": 999}}
+	store := repositories.NewLayoutStore(testLayout()[:2])
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"primary": primary, "secondary": secondary},
+		map[int]models.SourcePolicy{
+			1: {Vendors: []string{"primary", "secondary"}},
+			2: {Vendors: []string{"primary", "secondary"}},
+		},
+		zap.NewNop().Sugar())
+
+	poller.refresh(context.Background())
+
+	// BTC succeeded on the primary, only ETH moved on to the secondary
+	assert.Equal(t, []string{"ETH"}, secondary.calls)
+	assert.InDelta(t, 100.0, modelAt(t, store, 0).Price.USD.Float64(), 0.001)
+	assert.InDelta(t, 3000.0, modelAt(t, store, 1).Price.USD.Float64(), 0.001)
+}
+
+func TestPoller_Refresh_FallbackChainSkipsUnregisteredVendors(t *testing.T) {
+	last := &fakeClient{name: "last", prices: map[string]float64{"BTC": 42}}
+	down := &fakeClient{name: "down", err: errors.New("down")}
+	store := repositories.NewLayoutStore(testLayout()[:1])
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"down": down, "last": last},
+		map[int]models.SourcePolicy{1: {Vendors: []string{"down", "missing", "last"}}},
+		zap.NewNop().Sugar())
+
+	poller.refresh(context.Background())
+
+	assert.Equal(t, 1, down.callCount())
+	assert.Equal(t, 1, last.callCount())
+	assert.InDelta(t, 42.0, modelAt(t, store, 0).Price.USD.Float64(), 0.001)
+}
+
+func TestPoller_Refresh_FallbackChainExhausted(t *testing.T) {
+	a := &fakeClient{name: "a", err: errors.New("a down")}
+	b := &fakeClient{name: "b", err: errors.New("b down")}
+	store := repositories.NewLayoutStore(testLayout()[:1])
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"a": a, "b": b},
+		map[int]models.SourcePolicy{1: {Vendors: []string{"a", "b"}}},
+		zap.NewNop().Sugar())
+
+	poller.refresh(context.Background())
+
+	assert.Equal(t, 1, a.callCount())
+	assert.Equal(t, 1, b.callCount())
+	assert.Nil(t, store.GetLayout()[0].Model)
+	assert.Contains(t, statusAt(t, store, 0).LastError, "b down")
+}
+
+func TestPoller_Refresh_FailureKeepsLastKnownGoodModel(t *testing.T) {
+	client := &fakeClient{name: "single", prices: map[string]float64{"BTC": 100}}
+	store := repositories.NewLayoutStore(testLayout()[:1])
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"single": client},
+		singleVendors(map[int]string{1: "single"}),
+		zap.NewNop().Sugar())
+
+	poller.refresh(context.Background())
+	status := statusAt(t, store, 0)
+	assert.Equal(t, models.StateOK, status.State)
+	assert.Zero(t, status.ConsecutiveFailures)
+	require.NotNil(t, status.LastSuccess)
+	lastSuccess := *status.LastSuccess
+
+	client.err = errors.New("bitso down")
+	poller.refresh(context.Background())
+	poller.refresh(context.Background())
+
+	assert.InDelta(t, 100.0, modelAt(t, store, 0).Price.USD.Float64(), 0.001)
+	status = statusAt(t, store, 0)
+	assert.Equal(t, models.StateDegraded, status.State)
+	assert.Equal(t, 2, status.ConsecutiveFailures)
+	assert.Contains(t, status.LastError, "bitso down")
+	assert.Equal(t, lastSuccess, *status.LastSuccess)
+
+	client.err = nil
+	poller.refresh(context.Background())
+
+	status = statusAt(t, store, 0)
+	assert.Equal(t, models.StateOK, status.State)
+	assert.Zero(t, status.ConsecutiveFailures)
+	assert.Empty(t, status.LastError)
+}
+
+func TestPoller_Refresh_OpenCircuitFallsBackAndKeepsLastValue(t *testing.T) {
+	primary := &fakeClient{name: "primary", prices: map[string]float64{"BTC": 100}}
+	secondary := &fakeClient{name: "secondary", prices: map[string]float64{"BTC": 101}}
+	primaryBreaker := repositories.NewCircuitBreaker("primary", repositories.BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Hour})
+	secondaryBreaker := repositories.NewCircuitBreaker("secondary", repositories.BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Hour})
+	store := repositories.NewLayoutStore(testLayout()[:1])
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{
+			"primary":   repositories.WithCircuitBreaker(primary, primaryBreaker),
+			"secondary": repositories.WithCircuitBreaker(secondary, secondaryBreaker),
+		},
+		map[int]models.SourcePolicy{1: {Vendors: []string{"primary", "secondary"}}},
+		zap.NewNop().Sugar())
+
+	poller.refresh(context.Background())
+	assert.InDelta(t, 100.0, modelAt(t, store, 0).Price.USD.Float64(), 0.001)
+
+	// The primary circuit opens: the secondary answers without calling the primary
+	primaryBreaker.Record(errors.New("down"))
+	poller.refresh(context.Background())
+	assert.Equal(t, 1, primary.callCount())
+	assert.InDelta(t, 101.0, modelAt(t, store, 0).Price.USD.Float64(), 0.001)
+
+	// Every circuit open: nothing is called and the last value stays
+	secondaryBreaker.Record(errors.New("down"))
+	poller.refresh(context.Background())
+	assert.Equal(t, 1, primary.callCount())
+	assert.Equal(t, 1, secondary.callCount())
+	assert.InDelta(t, 101.0, modelAt(t, store, 0).Price.USD.Float64(), 0.001)
+	assert.Equal(t, models.StateDegraded, statusAt(t, store, 0).State)
+}
+
+func hedgedPoller(store *repositories.LayoutStore, primary, secondary repositories.CryptoClient) *Poller {
+	return NewPoller(store,
+		map[string]repositories.CryptoClient{"primary": primary, "secondary": secondary},
+		map[int]models.SourcePolicy{1: {Vendors: []string{"primary", "secondary"}, HedgeDelay: 20 * time.Millisecond}},
+		zap.NewNop().Sugar())
+}
+
+func TestPoller_Refresh_HedgeWinsOverSlowPrimary(t *testing.T) {
+	primary := &fakeClient{name: "primary", prices: map[string]float64{"BTC": 100}, delay: time.Second}
+	secondary := &fakeClient{name: "secondary", prices: map[string]float64{"BTC": 101}}
+	store := repositories.NewLayoutStore(testLayout()[:1])
+	won := testutil.ToFloat64(hedgedRequests.WithLabelValues("secondary", "won"))
+
+	start := time.Now()
+	hedgedPoller(store, primary, secondary).refresh(context.Background())
+
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.InDelta(t, 101.0, modelAt(t, store, 0).Price.USD.Float64(), 0.001)
+	assert.Equal(t, won+1, testutil.ToFloat64(hedgedRequests.WithLabelValues("secondary", "won")))
+	assert.Eventually(t, func() bool {
+		primary.mu.Lock()
+		defer primary.mu.Unlock()
+		return primary.cancelled == 1
+	}, time.Second, 5*time.Millisecond, "the losing request is cancelled")
+}
+
+func TestPoller_Refresh_FastPrimaryIsNotHedged(t *testing.T) {
+	primary := &fakeClient{name: "primary", prices: map[string]float64{"BTC": 100}}
+	secondary := &fakeClient{name: "secondary", prices: map[string]float64{"BTC": 101}}
+	store := repositories.NewLayoutStore(testLayout()[:1])
+
+	hedgedPoller(store, primary, secondary).refresh(context.Background())
+
+	assert.InDelta(t, 100.0, modelAt(t, store, 0).Price.USD.Float64(), 0.001)
+	assert.Zero(t, secondary.callCount())
+}
+
+func TestPoller_Refresh_HedgedPrimaryFailureFallsBackAtOnce(t *testing.T) {
+	primary := &fakeClient{name: "primary", err: errors.New("down")}
+	secondary := &fakeClient{name: "secondary", prices: map[string]float64{"BTC": 101}, delay: 50 * time.Millisecond}
+	store := repositories.NewLayoutStore(testLayout()[:1])
+	failed := testutil.ToFloat64(hedgedRequests.WithLabelValues("secondary", "failed"))
+	won := testutil.ToFloat64(hedgedRequests.WithLabelValues("secondary", "won"))
+
+	hedgedPoller(store, primary, secondary).refresh(context.Background())
+
+	assert.InDelta(t, 101.0, modelAt(t, store, 0).Price.USD.Float64(), 0.001)
+	assert.Equal(t, 1, secondary.callCount())
+	// A plain fallback is not a hedge
+	assert.Equal(t, won, testutil.ToFloat64(hedgedRequests.WithLabelValues("secondary", "won")))
+	assert.Equal(t, failed, testutil.ToFloat64(hedgedRequests.WithLabelValues("secondary", "failed")))
+}
+
+// marketClient quotes USD with bid and ask.
+type marketClient struct{ name string }
+
+func (m marketClient) Name() string { return m.name }
+
+func (m marketClient) GetPrice(ctx context.Context, symbol string) (*models.Money, error) {
+	bid, ask := models.DecimalFromFloat(99), models.DecimalFromFloat(101)
+	price := &models.Money{USD: models.DecimalFromFloat(100)}
+	price.SetMarket("USD", models.MarketData{Bid: &bid, Ask: &ask})
+	return price, nil
+}
+
+func TestPoller_Refresh_CopiesMarketDataOfSingleVendor(t *testing.T) {
+	store := repositories.NewLayoutStore(testLayout()[:1])
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"m": marketClient{name: "m"}},
+		singleVendors(map[int]string{1: "m"}),
+		zap.NewNop().Sugar())
+
+	poller.refresh(context.Background())
+
+	market := modelAt(t, store, 0).Market
+	require.Contains(t, market, "usd")
+	assert.InDelta(t, 2.0, market["usd"].Spread.Float64(), 0.001)
+}
+
+func TestPoller_Refresh_AggregatedPriceHasNoMarketData(t *testing.T) {
+	store := repositories.NewLayoutStore(testLayout()[:1])
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"a": marketClient{name: "a"}, "b": marketClient{name: "b"}},
+		map[int]models.SourcePolicy{1: {Vendors: []string{"a", "b"}, Aggregation: models.AggregationMedian}},
+		zap.NewNop().Sugar())
+
+	poller.refresh(context.Background())
+
+	assert.InDelta(t, 100.0, modelAt(t, store, 0).Price.USD.Float64(), 0.001)
+	assert.Nil(t, modelAt(t, store, 0).Market)
+}
+
+func TestPoller_Validate(t *testing.T) {
+	poller := NewPoller(repositories.NewLayoutStore(nil),
+		map[string]repositories.CryptoClient{"bitso": &fakeClient{name: "bitso"}},
+		map[int]models.SourcePolicy{
+			1: {Vendors: []string{"bitso"}},
+			2: {Vendors: []string{"bitso", "coinbsae"}},
+		},
+		zap.NewNop().Sugar())
+
+	err := poller.Validate()
+
+	require.Error(t, err)
+	assert.Equal(t, `component 2: vendor "coinbsae" is not registered`, err.Error())
+}
+
+func TestPoller_Validate_AllRegistered(t *testing.T) {
+	poller := NewPoller(repositories.NewLayoutStore(nil),
+		map[string]repositories.CryptoClient{"bitso": &fakeClient{name: "bitso"}},
+		singleVendors(map[int]string{1: "bitso"}),
+		zap.NewNop().Sugar())
+
+	assert.NoError(t, poller.Validate())
+}
+
+func TestPoller_Refresh_DeterministicWithScriptedMock(t *testing.T) {
+	replay := map[string][]models.Money{"BTC": {{USD: models.DecimalFromFloat(50000), MXN: models.DecimalFromFloat(850000)}, {USD: models.DecimalFromFloat(50100), MXN: models.DecimalFromFloat(851700)}}}
+	primary := adapters.NewMockClient(adapters.WithReplay(replay), adapters.WithScript(models.ErrRateLimited))
+	backup := adapters.NewMockClient(adapters.WithReplay(map[string][]models.Money{"BTC": {{USD: models.DecimalFromFloat(49000), MXN: models.DecimalFromFloat(833000)}}}))
+
+	store := repositories.NewLayoutStore(models.Layout{{ID: 1, Component: "crypto_btc"}})
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"primary": primary, "backup": backup},
+		map[int]models.SourcePolicy{1: {Vendors: []string{"primary", "backup"}}},
+		zap.NewNop().Sugar())
+
+	var got []float64
+	for i := 0; i < 3; i++ {
+		poller.refresh(context.Background())
+		got = append(got, modelAt(t, store, 0).Price.USD.Float64())
+	}
+
+	// The scripted rate limit sends the first cycle to the backup vendor.
+	assert.Equal(t, []float64{49000, 50000, 50100}, got)
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"context"
+	"crypto-aggregator-service/internal/models"
+	"crypto-aggregator-service/internal/repositories"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Streamer feeds the layout from a live vendor stream. Components whose
+// primary vendor is the stream vendor (and that do not aggregate) are taken
+// away from the Poller once their first stream price arrives and handed back
+// to it as soon as the connection drops.
+type Streamer struct {
+	stream repositories.BookStream
+	poller *Poller
+	logger *zap.SugaredLogger
+
+	// MinBackoff and MaxBackoff bound the wait between reconnect attempts.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Books lists the books the vendor trades. When set, only those books are
+	// subscribed and components left without any keep polling.
+	Books repositories.BookLister
+
+	mu    sync.Mutex
+	books map[string]repositories.BookUpdate // latest state per book
+	live  map[int]bool                       // components priced by this connection
+}
+
+func NewStreamer(stream repositories.BookStream, poller *Poller, l *zap.SugaredLogger) *Streamer {
+	return &Streamer{
+		stream:     stream,
+		poller:     poller,
+		logger:     l,
+		MinBackoff: time.Second,
+		MaxBackoff: 30 * time.Second,
+		books:      make(map[string]repositories.BookUpdate),
+		live:       make(map[int]bool),
+	}
+}
+
+// Start keeps the stream connected until ctx is done, reconnecting with
+// exponential backoff.
+func (s *Streamer) Start(ctx context.Context) {
+	targets := s.targets()
+	if s.Books != nil {
+		targets = s.listed(ctx, targets)
+	}
+	if len(targets) == 0 {
+		s.logger.Warn("No components sourced from stream vendor", zap.String("vendor", s.stream.Name()))
+		return
+	}
+
+	var (
+		ids   []int
+		books []string
+	)
+	for symbol, ts := range targets {
+		for _, t := range ts {
+			ids = append(ids, t.id)
+		}
+		for _, currency := range streamCurrencies(ts) {
+			books = append(books, bookName(symbol, currency))
+		}
+	}
+
+	s.logger.Info("Starting stream ingestion", zap.String("vendor", s.stream.Name()), zap.Strings("books", books))
+
+	backoff := s.MinBackoff
+	for {
+		connected := false
+		err := s.stream.Stream(ctx, books,
+			func() {
+				connected = true
+				s.logger.Info("Stream connected", zap.Strings("books", books))
+			},
+			func(u repositories.BookUpdate) { s.handle(ctx, u, targets) })
+
+		s.poller.SetStreamed(ids, false)
+		s.reset()
+		if ctx.Err() != nil {
+			return
+		}
+
+		if connected {
+			backoff = s.MinBackoff
+		}
+		s.logger.Warn("Stream disconnected, polling until reconnect",
+			zap.Duration("backoff", backoff),
+			zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, s.MaxBackoff)
+	}
+}
+
+// streamTarget is a layout slot fed by the stream.
+type streamTarget struct {
+	index      int
+	id         int
+	currencies []string
+}
+
+// streamCurrencies is the union of the currencies the targets of one symbol
+// are quoted in, in first seen order.
+func streamCurrencies(targets []streamTarget) []string {
+	var currencies []string
+	seen := make(map[string]bool)
+	for _, t := range targets {
+		for _, c := range t.currencies {
+			if !seen[c] {
+				seen[c] = true
+				currencies = append(currencies, c)
+			}
+		}
+	}
+	return currencies
+}
+
+// listed drops the currencies the vendor has no book for, and the targets
+// left without any. Every target is kept when the books cannot be listed.
+func (s *Streamer) listed(ctx context.Context, targets map[string][]streamTarget) map[string][]streamTarget {
+	names, err := s.Books.Books(ctx)
+	if err != nil {
+		s.logger.Warn("Failed to list stream books, subscribing to every book", zap.Error(err))
+		return targets
+	}
+	exists := make(map[string]bool, len(names))
+	for _, name := range names {
+		exists[name] = true
+	}
+
+	filtered := make(map[string][]streamTarget)
+	for symbol, ts := range targets {
+		for _, t := range ts {
+			var currencies []string
+			for _, currency := range t.currencies {
+				if exists[bookName(symbol, currency)] {
+					currencies = append(currencies, currency)
+				}
+			}
+			if len(currencies) == 0 {
+				continue
+			}
+			t.currencies = currencies
+			filtered[symbol] = append(filtered[symbol], t)
+		}
+	}
+	return filtered
+}
+
+// targets groups the streamable components by symbol.
+func (s *Streamer) targets() map[string][]streamTarget {
+	targets := make(map[string][]streamTarget)
+	for i, comp := range s.poller.Store.GetLayout() {
+		policy, ok := s.poller.policies[comp.ID]
+		if !ok || len(policy.Vendors) == 0 || policy.Aggregation != models.AggregationNone {
+			continue
+		}
+		if policy.Vendors[0] != s.stream.Name() {
+			continue
+		}
+		symbol := comp.Symbol()
+		targets[symbol] = append(targets[symbol], streamTarget{index: i, id: comp.ID, currencies: policy.QuoteCurrencies()})
+	}
+	return targets
+}
+
+// handle merges an update into the book state and publishes the new price of
+// its symbol.
+func (s *Streamer) handle(ctx context.Context, u repositories.BookUpdate, targets map[string][]streamTarget) {
+	base, _, ok := strings.Cut(u.Book, "_")
+	if !ok {
+		return
+	}
+	symbol := strings.ToUpper(base)
+	if len(targets[symbol]) == 0 {
+		return
+	}
+
+	var (
+		price  models.Money
+		priced bool
+	)
+	s.mu.Lock()
+	state := s.books[u.Book]
+	if u.Last.Sign() > 0 {
+		state.Last = u.Last
+	}
+	if u.Bid.Sign() > 0 {
+		state.Bid = u.Bid
+	}
+	if u.Ask.Sign() > 0 {
+		state.Ask = u.Ask
+	}
+	if u.Time.After(state.Time) {
+		state.Time = u.Time
+	}
+	s.books[u.Book] = state
+
+	for _, currency := range streamCurrencies(targets[symbol]) {
+		book := s.books[bookName(symbol, currency)]
+		if v := bookPrice(book); v.Sign() > 0 && price.Set(currency, v) {
+			priced = true
+			price.SetMarket(currency, models.MarketData{Bid: positive(book.Bid), Ask: positive(book.Ask)})
+			price.SetSourceTime(book.Time)
+		}
+	}
+	s.mu.Unlock()
+
+	if !priced {
+		return
+	}
+	for _, t := range targets[symbol] {
+		s.poller.Apply(ctx, t.index, symbol, s.stream.Name(), price)
+	}
+	s.markLive(targets[symbol])
+}
+
+// markLive takes components away from the poller once the stream published
+// their first price.
+func (s *Streamer) markLive(targets []streamTarget) {
+	var ids []int
+	s.mu.Lock()
+	for _, t := range targets {
+		if !s.live[t.id] {
+			s.live[t.id] = true
+			ids = append(ids, t.id)
+		}
+	}
+	s.mu.Unlock()
+
+	if len(ids) > 0 {
+		s.poller.SetStreamed(ids, true)
+		s.logger.Info("Stream priced components, pausing polling", zap.Ints("components", ids))
+	}
+}
+
+// reset drops the book state so a reconnect never mixes in stale prices.
+func (s *Streamer) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.books = make(map[string]repositories.BookUpdate)
+	s.live = make(map[int]bool)
+}
+
+// bookPrice is the last trade, or the mid price until a trade is seen.
+func bookPrice(b repositories.BookUpdate) models.Decimal {
+	switch {
+	case b.Last.Sign() > 0:
+		return b.Last
+	case b.Bid.Sign() > 0 && b.Ask.Sign() > 0:
+		return b.Bid.Add(b.Ask).Quo(models.DecimalFromInt(2))
+	default:
+		return models.Decimal{}
+	}
+}
+
+// positive returns nil for a book side that was not seen yet.
+func positive(v models.Decimal) *models.Decimal {
+	if v.Sign() <= 0 {
+		return nil
+	}
+	return &v
+}
+
+func bookName(symbol, currency string) string {
+	return strings.ToLower(symbol + "_" + currency)
+}
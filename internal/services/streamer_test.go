@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"crypto-aggregator-service/internal/models"
+	"crypto-aggregator-service/internal/repositories"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newBitsoStandIn emulates ws.bitso.com. The first connection sends one BTC
+// trade and drops once release is closed; later connections stay idle.
+func newBitsoStandIn(t *testing.T, release <-chan struct{}, connections *atomic.Int32) *httptest.Server {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		n := connections.Add(1)
+
+		// btc_mxn and btc_usd, trades and orders each
+		for i := 0; i < 4; i++ {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+
+		if n == 1 {
+			_ = conn.WriteMessage(websocket.TextMessage,
+				[]byte(`{"type":"trades","book":"btc_mxn","payload":[{"i":1,"a":"0.1","r":"850000","v":"85000","t":0}]}`))
+			<-release
+			return
+		}
+		_, _, _ = conn.ReadMessage()
+	}))
+}
+
+func TestStreamer_UpdatesStoreAndReconnects(t *testing.T) {
+	release := make(chan struct{})
+	var connections atomic.Int32
+	server := newBitsoStandIn(t, release, &connections)
+	defer server.Close()
+
+	bitso := &fakeClient{name: "bitso", prices: map[string]float64{"BTC": 1, "ETH": 1, "XRP": 1}}
+	coinbase := &fakeClient{name: "coinbase", prices: map[string]float64{"ETH": 3000, "XRP": 0.5}}
+	store := repositories.NewLayoutStore(testLayout())
+	poller := NewPoller(store,
+		map[string]repositories.CryptoClient{"bitso": bitso, "coinbase": coinbase},
+		map[int]models.SourcePolicy{
+			1: {Vendors: []string{"bitso", "coinbase"}},
+			2: {Vendors: []string{"coinbase"}},
+			3: {Vendors: []string{"bitso", "coinbase"}, Aggregation: models.AggregationMedian},
+		},
+		zap.NewNop().Sugar(),
+		WithFX(repositories.NewStaticFXProvider(map[string]float64{"MXN": 17})))
+
+	streamer := NewStreamer(repositories.NewBitsoStream("ws"+strings.TrimPrefix(server.URL, "http")), poller, zap.NewNop().Sugar())
+	streamer.MinBackoff = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		streamer.Start(ctx)
+		close(stopped)
+	}()
+
+	require.Eventually(t, func() bool {
+		model, ok := store.GetLayout()[0].Model.(models.Model)
+		return ok && model.Price.MXN.Float64() == 850000
+	}, 2*time.Second, 10*time.Millisecond)
+
+	btc := modelAt(t, store, 0)
+	assert.Equal(t, models.Ticker("BTC"), btc.TickerSymbol)
+	assert.InDelta(t, 850000.0/17, btc.Price.USD.Float64(), 0.001)
+	assert.Equal(t, []string{"USD"}, btc.Price.Derived)
+
+	// Only the single-source bitso component is streamed.
+	require.Eventually(t, func() bool { return poller.isStreamed(1) }, time.Second, 10*time.Millisecond)
+	assert.False(t, poller.isStreamed(2))
+	assert.False(t, poller.isStreamed(3))
+
+	poller.refresh(ctx)
+	assert.Equal(t, []string{"XRP"}, bitso.calls, "streamed components must not be polled")
+	assert.Equal(t, 850000.0, modelAt(t, store, 0).Price.MXN.Float64())
+
+	// Dropping the connection hands BTC back to the poller and reconnects.
+	close(release)
+	require.Eventually(t, func() bool { return connections.Load() == 2 }, 2*time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("streamer did not stop")
+	}
+	assert.False(t, poller.isStreamed(1))
+}
+
+// staticBooks is a BookLister with a fixed answer.
+type staticBooks []string
+
+func (b staticBooks) Books(context.Context) ([]string, error) { return b, nil }
+
+func TestStreamer_SubscribesOnlyListedBooksAndPollsUntilPriced(t *testing.T) {
+	subscribed := make(chan string, 8)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var msg struct {
+				Book string `json:"book"`
+				Type string `json:"type"`
+			}
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			subscribed <- msg.Book + "/" + msg.Type
+		}
+	}))
+	defer server.Close()
+
+	store := repositories.NewLayoutStore(testLayout())
+	poller := NewPoller(store, nil, singleVendors(map[int]string{1: "bitso", 2: "coinbase", 3: "bitso"}), zap.NewNop().Sugar())
+	streamer := NewStreamer(repositories.NewBitsoStream("ws"+strings.TrimPrefix(server.URL, "http")), poller, zap.NewNop().Sugar())
+	streamer.Books = staticBooks{"btc_mxn", "eth_mxn", "usd_mxn"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go streamer.Start(ctx)
+
+	var got []string
+	for len(got) < 2 {
+		select {
+		case sub := <-subscribed:
+			got = append(got, sub)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("subscriptions so far: %v", got)
+		}
+	}
+	assert.ElementsMatch(t, []string{"btc_mxn/trades", "btc_mxn/orders"}, got)
+
+	// Subscribed but not priced yet, so BTC keeps polling; XRP has no book
+	select {
+	case sub := <-subscribed:
+		t.Fatalf("unexpected subscription %s", sub)
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.False(t, poller.isStreamed(1))
+	assert.False(t, poller.isStreamed(3))
+}
+
+func TestStreamer_NoStreamableComponents(t *testing.T) {
+	store := repositories.NewLayoutStore(testLayout())
+	poller := NewPoller(store, nil, singleVendors(map[int]string{1: "coinbase", 2: "coinbase", 3: "kraken"}), zap.NewNop().Sugar())
+	streamer := NewStreamer(repositories.NewBitsoStream("ws://127.0.0.1:0"), poller, zap.NewNop().Sugar())
+
+	done := make(chan struct{})
+	go func() {
+		streamer.Start(context.Background())
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("streamer should return when nothing is streamed")
+	}
+}
+
+func TestBookPrice(t *testing.T) {
+	ten, eight, twelve := models.DecimalFromInt(10), models.DecimalFromInt(8), models.DecimalFromInt(12)
+	assert.Equal(t, "10", bookPrice(repositories.BookUpdate{Last: ten, Bid: eight, Ask: twelve}).String())
+	assert.Equal(t, "11", bookPrice(repositories.BookUpdate{Bid: ten, Ask: twelve}).String())
+	assert.True(t, bookPrice(repositories.BookUpdate{Bid: ten}).IsZero())
+}